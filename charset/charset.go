@@ -0,0 +1,150 @@
+package charset
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// BOM is the byte order mark that starts some UTF-8 files.
+const BOM = "\xef\xbb\xbf"
+
+var (
+	// ErrBadParameter is the error returned when invalid input is provided.
+	ErrBadParameter = errors.New("Invalid parameter")
+
+	// ErrBadEncoding is the error returned when text isn't valid in its charset.
+	ErrBadEncoding = errors.New("Invalid character encoding")
+)
+
+// Charset is the character encoding of a catalog file. Apart from UTF-8, charsets encode each character in a single
+// byte, so the fields of a record are at the same byte offsets in any of them.
+type Charset struct {
+	name string
+
+	// table decodes each byte, or is nil for UTF-8. Bytes the charset doesn't define decode to utf8.RuneError.
+	table *[256]rune
+
+	// newlines are the bytes that end a line.
+	newlines []byte
+}
+
+var (
+	// UTF8 is UTF-8, which includes ASCII.
+	UTF8 = &Charset{name: "utf-8", newlines: []byte{'\n'}}
+
+	// Windows1252 is the Windows Western European code page.
+	Windows1252 = newCharset("windows-1252", charmap.Windows1252, '\n')
+
+	// Latin1 is ISO 8859-1.
+	Latin1 = newCharset("iso-8859-1", charmap.ISO8859_1, '\n')
+
+	// EBCDIC is IBM code page 037, the US and Canada EBCDIC code page. Lines end with LF (0x25) or NL (0x15).
+	EBCDIC = newCharset("ibm037", charmap.CodePage037, 0x25, 0x15)
+
+	// charsets are the charsets by name and alias, in lower case.
+	charsets = map[string]*Charset{
+		"utf-8":        UTF8,
+		"utf8":         UTF8,
+		"ascii":        UTF8,
+		"windows-1252": Windows1252,
+		"cp1252":       Windows1252,
+		"iso-8859-1":   Latin1,
+		"latin-1":      Latin1,
+		"latin1":       Latin1,
+		"ibm037":       EBCDIC,
+		"cp037":        EBCDIC,
+		"ebcdic":       EBCDIC,
+	}
+)
+
+func newCharset(name string, cm *charmap.Charmap, newlines ...byte) *Charset {
+	c := &Charset{name: name, table: new([256]rune), newlines: newlines}
+	for b := range c.table {
+		c.table[b] = cm.DecodeByte(byte(b))
+	}
+	return c
+}
+
+// Lookup returns the charset with a name or alias, such as "utf-8", "windows-1252", "latin-1" or "ebcdic".
+// Case is ignored.
+func Lookup(name string) (*Charset, error) {
+	c, ok := charsets[strings.ToLower(name)]
+	if !ok {
+		return nil, errors.Wrapf(ErrBadParameter, "unknown charset %q", name)
+	}
+	return c, nil
+}
+
+// Name returns the canonical name of the charset.
+func (c *Charset) Name() string {
+	return c.name
+}
+
+func (c *Charset) String() string {
+	return c.name
+}
+
+// Newlines returns the bytes that end a line.
+func (c *Charset) Newlines() []byte {
+	return c.newlines
+}
+
+// Newline returns the byte used to end a line that is written in the charset.
+func (c *Charset) Newline() byte {
+	return c.newlines[0]
+}
+
+// Space returns the byte that encodes a space.
+func (c *Charset) Space() byte {
+	if c.table != nil {
+		for b, r := range c.table {
+			if r == ' ' {
+				return byte(b)
+			}
+		}
+	}
+	return ' '
+}
+
+// Invalid returns the offset of the first byte of text that isn't part of a character of the charset,
+// or -1 if all of it is valid.
+func (c *Charset) Invalid(text []byte) int {
+	if c.table == nil {
+		for i := 0; i < len(text); {
+			r, size := utf8.DecodeRune(text[i:])
+			if r == utf8.RuneError && size <= 1 {
+				return i
+			}
+			i += size
+		}
+		return -1
+	}
+
+	for i, b := range text {
+		if c.table[b] == utf8.RuneError {
+			return i
+		}
+	}
+	return -1
+}
+
+// Decode returns text as UTF-8. Invalid bytes are replaced by utf8.RuneError.
+func (c *Charset) Decode(text []byte) []byte {
+	if c.table == nil {
+		return bytes.ToValidUTF8(text, []byte(string(utf8.RuneError)))
+	}
+
+	decoded := make([]byte, 0, len(text))
+	for _, b := range text {
+		if b < utf8.RuneSelf && c.table[b] == rune(b) {
+			decoded = append(decoded, b)
+			continue
+		}
+		decoded = append(decoded, string(c.table[b])...)
+	}
+	return decoded
+}
@@ -0,0 +1,67 @@
+package charset
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type charsetTestSuite struct {
+	suite.Suite
+}
+
+func Test_Charset(t *testing.T) {
+	s := new(charsetTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *charsetTestSuite) Test_Lookup_Alias_ReturnsCharset() {
+	for name, expected := range map[string]*Charset{
+		"UTF-8":        UTF8,
+		"Windows-1252": Windows1252,
+		"latin-1":      Latin1,
+		"EBCDIC":       EBCDIC,
+	} {
+		c, err := Lookup(name)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), expected, c)
+	}
+}
+
+func (s *charsetTestSuite) Test_Lookup_Unknown_ReturnsError() {
+	_, err := Lookup("shift-jis")
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *charsetTestSuite) Test_Decode_Windows1252_ReturnsUTF8() {
+	text := []byte("Jalape\xf1o \x80")
+	require.Equal(s.T(), -1, Windows1252.Invalid(text))
+	require.Equal(s.T(), "Jalapeño €", string(Windows1252.Decode(text)))
+}
+
+func (s *charsetTestSuite) Test_Invalid_Windows1252_UndefinedByte_ReturnsOffset() {
+	require.Equal(s.T(), 3, Windows1252.Invalid([]byte("abc\x81")))
+}
+
+func (s *charsetTestSuite) Test_Decode_Latin1_ReturnsUTF8() {
+	require.Equal(s.T(), "Jalapeño", string(Latin1.Decode([]byte("Jalape\xf1o"))))
+}
+
+func (s *charsetTestSuite) Test_Decode_EBCDIC_ReturnsUTF8() {
+	// "Rice 12" in code page 037.
+	text := []byte{0xd9, 0x89, 0x83, 0x85, 0x40, 0xf1, 0xf2}
+	require.Equal(s.T(), -1, EBCDIC.Invalid(text))
+	require.Equal(s.T(), "Rice 12", string(EBCDIC.Decode(text)))
+	require.Equal(s.T(), []byte{0x25, 0x15}, EBCDIC.Newlines())
+	require.Equal(s.T(), byte(0x25), EBCDIC.Newline())
+	require.Equal(s.T(), byte(0x40), EBCDIC.Space())
+	require.Equal(s.T(), byte(' '), UTF8.Space())
+}
+
+func (s *charsetTestSuite) Test_Invalid_UTF8_ReturnsOffset() {
+	require.Equal(s.T(), -1, UTF8.Invalid([]byte("Jalapeño")))
+	require.Equal(s.T(), 6, UTF8.Invalid([]byte("Jalape\xf1o")))
+	require.Equal(s.T(), "Jalape�o", string(UTF8.Decode([]byte("Jalape\xf1o"))))
+}
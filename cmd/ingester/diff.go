@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/jessejohnston/ProductIngester/charset"
+	"github.com/jessejohnston/ProductIngester/diff"
+	"github.com/jessejohnston/ProductIngester/input"
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+)
+
+// diffMain compares two catalog files, writing the changes from the old catalog to the new one.
+func diffMain(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	layoutFile := flags.String("layout", "", "JSON or YAML record layout file of both files (default: standard store layout)")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of parsing workers")
+	flagPositions := flags.String("flags", "", "comma-separated flag names for each position of the flags field, empty to ignore a position (default: "+strings.Join(product.DefaultFlagPositions, ",")+")")
+	format := flags.String("format", "text", "output format: text or jsonl")
+	charsetName := flags.String("charset", charset.UTF8.Name(), "character encoding of both files: utf-8, windows-1252, latin-1 or ebcdic")
+	leniencyNames := flags.String("leniency", "", "comma-separated normalizations of lines that aren't the record length: pad_short and trim_long (default: none)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ingester diff [options] <old filename> <new filename>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "jsonl" {
+		log.Fatalf("Unknown output format %s", *format)
+	}
+
+	layout := parser.DefaultLayout()
+	if *layoutFile != "" {
+		var err error
+		layout, err = parser.LoadLayout(*layoutFile)
+		if err != nil {
+			log.Fatalf("Error loading layout %s: %v", *layoutFile, err)
+		}
+	}
+
+	cs, err := charset.Lookup(*charsetName)
+	if err != nil {
+		log.Fatalf("Error choosing charset: %v", err)
+	}
+
+	leniency, err := parser.ParseLeniency(strings.Split(*leniencyNames, ",")...)
+	if err != nil {
+		log.Fatalf("Error choosing leniency: %v", err)
+	}
+
+	var positions []string
+	if *flagPositions != "" {
+		positions = strings.Split(*flagPositions, ",")
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	// A line that fails to parse would look like a removed product, so either file failing fails the diff.
+	var catalogs [2][]*product.Record
+	for i, filename := range flags.Args()[:2] {
+		records, err := readCatalog(ctx, filename, layout, positions, parser.WithConcurrency(*workers), parser.WithCharset(cs), parser.WithLeniency(leniency))
+		if err != nil {
+			log.Fatalf("Error reading %s: %v", filename, err)
+		}
+		catalogs[i] = records
+	}
+
+	changes, err := diff.Compare(catalogs[0], catalogs[1])
+	if err != nil {
+		log.Fatalf("Error comparing catalogs: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	for _, c := range changes {
+		if *format == "jsonl" {
+			err = encoder.Encode(c)
+		} else {
+			_, err = fmt.Println(c)
+		}
+		if err != nil {
+			log.Fatalf("Error writing changes: %v", err)
+		}
+	}
+}
+
+// readCatalog parses every record of a file, or of every file in a zip archive, failing at the first line that
+// fails to parse.
+func readCatalog(ctx context.Context, filename string, layout parser.Layout, flagPositions []string, opts ...parser.Option) ([]*product.Record, error) {
+	var records []*product.Record
+
+	err := input.Each(filename, func(name string, r io.Reader) error {
+		p, err := getParser(r, layout, flagPositions, append(opts, parser.WithStopOnError())...)
+		if err != nil {
+			return err
+		}
+
+		var failure error
+		for result := range p.Results(ctx) {
+			if result.Err != nil {
+				if failure == nil {
+					failure = result.Err
+				}
+				continue
+			}
+			records = append(records, result.Record)
+		}
+
+		// The line's own error explains the failure better than the error budget being spent.
+		if failure == nil {
+			failure = p.Err()
+		}
+		if failure != nil && name != filename {
+			return errors.Wrap(failure, name)
+		}
+		return failure
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
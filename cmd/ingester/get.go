@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/store"
+	"github.com/jessejohnston/ProductIngester/tax"
+)
+
+// getMain writes the catalog store entries of products, by ID, as JSON Lines. With -as-of or -history, it writes
+// their price versions instead.
+func getMain(args []string) {
+	flags := flag.NewFlagSet("get", flag.ExitOnError)
+	dbFile := flags.String("db", "", "catalog store file")
+	asOf := flags.String("as-of", "", "write the version of each product valid at this RFC 3339 time or date ("+tax.DateFormat+")")
+	history := flags.Bool("history", false, "write every version of each product")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ingester get -db <file> [-as-of <time> | -history] <id>...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *dbFile == "" || flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	var at time.Time
+	if *asOf != "" {
+		var err error
+		at, err = parseTime(*asOf)
+		if err != nil {
+			log.Fatalf("Bad time %s: %v", *asOf, err)
+		}
+	}
+
+	s, err := store.Open(*dbFile)
+	if err != nil {
+		log.Fatalf("Error opening store %s: %v", *dbFile, err)
+	}
+	defer s.Close()
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false)
+	for _, arg := range flags.Args() {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			log.Fatalf("Bad product ID %s", arg)
+		}
+
+		var values []interface{}
+		switch {
+		case *history:
+			versions, err := s.History(id)
+			if err != nil {
+				log.Fatalf("Error getting product %d history: %v", id, err)
+			}
+			for _, v := range versions {
+				values = append(values, v)
+			}
+		case !at.IsZero():
+			v, err := s.AsOf(id, at)
+			if err != nil {
+				log.Fatalf("Error getting product %d as of %s: %v", id, *asOf, err)
+			}
+			values = append(values, v)
+		default:
+			entry, err := s.Get(id)
+			if err != nil {
+				log.Fatalf("Error getting product %d: %v", id, err)
+			}
+			values = append(values, entry)
+		}
+
+		for _, v := range values {
+			if err := encoder.Encode(v); err != nil {
+				log.Fatalf("Error writing product %d: %v", id, err)
+			}
+		}
+	}
+}
+
+// parseTime parses an RFC 3339 time, or a date as midnight local time.
+func parseTime(text string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(tax.DateFormat, text, time.Local)
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
-	"github.com/jessejohnston/ProductIngester/product"
+	"context"
+
+	"github.com/jessejohnston/ProductIngester/parser"
 )
 
 // Parser defines the behavior of a product catalog parser.
 type Parser interface {
-	Parse() (<-chan *product.Record, <-chan error, <-chan bool)
+	Results(ctx context.Context) <-chan parser.Result
+	Err() error
+	Stats() parser.Stats
 }
@@ -1,63 +1,328 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/jessejohnston/ProductIngester/charset"
+	"github.com/jessejohnston/ProductIngester/export"
+	"github.com/jessejohnston/ProductIngester/input"
 	"github.com/jessejohnston/ProductIngester/parser"
 	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/jessejohnston/ProductIngester/sink"
+	"github.com/jessejohnston/ProductIngester/tax"
 	"github.com/pkg/errors"
 )
 
 func main() {
-	args := os.Args
-	if len(args) < 2 {
-		println("usage: ingest <filename>")
+	// Subcommands have their own options.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			diffMain(os.Args[2:])
+			return
+		case "get":
+			getMain(os.Args[2:])
+			return
+		case "serve":
+			serveMain(os.Args[2:])
+			return
+		case "watch":
+			watchMain(os.Args[2:])
+			return
+		}
+	}
+
+	layoutFile := flag.String("layout", "", "JSON or YAML record layout file (default: standard store layout)")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of parsing workers")
+	rejectsFile := flag.String("rejects", "", "write lines that fail to parse to this file, and a report of each failure to <file>.errors.csv")
+	maxErrors := flag.Int("max-errors", -1, "fail the run if more than this many lines fail to parse (default: no limit)")
+	maxErrorRate := flag.Float64("max-error-rate", -1, "fail the run if more than this percentage of lines fail to parse (default: no limit)")
+	stopOnError := flag.Bool("stop-on-error", false, "fail the run at the first line that fails to parse")
+	flagPositions := flag.String("flags", "", "comma-separated flag names for each position of the flags field, empty to ignore a position (default: "+strings.Join(product.DefaultFlagPositions, ",")+")")
+	taxFile := flag.String("tax", "", "JSON or YAML tax table file (default: a single rate of "+parser.DefaultTaxRate.String()+")")
+	store := flag.String("store", "", "store the file is from, for the tax table")
+	region := flag.String("region", "", "region the store is in, for the tax table")
+	taxDate := flag.String("tax-date", time.Now().Format(tax.DateFormat), "date of the tax rates to use from the tax table")
+	format := flag.String("format", export.FormatText, "output format: text, jsonl or csv")
+	columns := flag.String("columns", "", "comma-separated columns of csv output (default: "+strings.Join(export.DefaultCSVColumns, ",")+")")
+	outputFile := flag.String("output", "", "write records to this file (default: standard output)")
+	dbFile := flag.String("db", "", "also upsert the records into the catalog store in this file")
+	sinksFile := flag.String("sinks", "", "YAML file of sinks to deliver records to, instead of -format, -columns, -output and -db")
+	unitPricing := flag.String("unit-pricing", "US", "unit pricing rules of the jurisdiction, US or EU")
+	charsetName := flag.String("charset", charset.UTF8.Name(), "character encoding of the input: utf-8, windows-1252, latin-1 or ebcdic")
+	leniencyNames := flag.String("leniency", "", "comma-separated normalizations of lines that aren't the record length: pad_short and trim_long (default: none)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ingester [options] <filename>")
+		fmt.Fprintln(os.Stderr, "       ingester diff [options] <old filename> <new filename>")
+		fmt.Fprintln(os.Stderr, "       ingester get -db <file> <id>...")
+		fmt.Fprintln(os.Stderr, "       ingester serve [options]")
+		fmt.Fprintln(os.Stderr, "       ingester watch [options] <inbox directory>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	filename := os.Args[1]
-	file, err := os.Open(filename)
+	sinks, err := getSinks(*sinksFile, *format, *columns, *outputFile, *dbFile)
 	if err != nil {
+		log.Fatalf("Error choosing sinks: %v", err)
+	}
+
+	layout := parser.DefaultLayout()
+	if *layoutFile != "" {
+		var err error
+		layout, err = parser.LoadLayout(*layoutFile)
+		if err != nil {
+			log.Fatalf("Error loading layout %s: %v", *layoutFile, err)
+		}
+	}
+
+	pricing, err := product.UnitPricingFor(*unitPricing)
+	if err != nil {
+		log.Fatalf("Error choosing unit pricing: %v", err)
+	}
+
+	cs, err := charset.Lookup(*charsetName)
+	if err != nil {
+		log.Fatalf("Error choosing charset: %v", err)
+	}
+
+	leniency, err := parser.ParseLeniency(strings.Split(*leniencyNames, ",")...)
+	if err != nil {
+		log.Fatalf("Error choosing leniency: %v", err)
+	}
+
+	opts := []parser.Option{parser.WithConcurrency(*workers), parser.WithUnitPricing(pricing), parser.WithCharset(cs), parser.WithLeniency(leniency)}
+
+	if *taxFile != "" {
+		table, err := tax.LoadTable(*taxFile)
+		if err != nil {
+			log.Fatalf("Error loading tax table %s: %v", *taxFile, err)
+		}
+		date, err := time.Parse(tax.DateFormat, *taxDate)
+		if err != nil {
+			log.Fatalf("Error parsing tax date %s: %v", *taxDate, err)
+		}
+		opts = append(opts, parser.WithTaxPolicy(table.Policy(*store, *region, date)))
+	}
+
+	filename := flag.Arg(0)
+	if _, err := os.Stat(filename); err != nil {
 		log.Fatalf("Error opening file %s: %v", filename, err)
 	}
-	defer file.Close()
 
-	p, err := getParser(file)
+	if *stopOnError {
+		opts = append(opts, parser.WithStopOnError())
+	} else if *maxErrors >= 0 {
+		opts = append(opts, parser.WithMaxErrors(*maxErrors))
+	}
+	if *maxErrorRate >= 0 {
+		opts = append(opts, parser.WithMaxErrorRate(*maxErrorRate))
+	}
+
+	if *rejectsFile != "" {
+		rejects, err := os.Create(*rejectsFile)
+		if err != nil {
+			log.Fatalf("Error creating reject file %s: %v", *rejectsFile, err)
+		}
+		defer rejects.Close()
+
+		report, err := os.Create(*rejectsFile + ".errors.csv")
+		if err != nil {
+			log.Fatalf("Error creating reject report %s.errors.csv: %v", *rejectsFile, err)
+		}
+		defer report.Close()
+
+		// The runs of a zip archive's files share the reject file and report.
+		shared, err := parser.NewRejects(rejects, report)
+		if err != nil {
+			log.Fatalf("Error creating reject report: %v", err)
+		}
+		opts = append(opts, parser.WithSharedRejects(shared))
+	}
+
+	var positions []string
+	if *flagPositions != "" {
+		positions = strings.Split(*flagPositions, ",")
+	}
+
+	// Stop parsing on interrupt.
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	stats, err := ingest(ctx, filename, layout, positions, sinks, opts...)
+	printStats(os.Stderr, stats)
 	if err != nil {
-		log.Fatalf("Error creating parser: %v", err)
+		log.Fatal(err)
 	}
+}
 
-	// Start parsing, receiving a stream of records and parsing errors.
-	records, errors, done := p.Parse()
+// ingest parses each catalog in a file, such as each file of a zip archive, in a separate run, logging each line
+// that fails to parse. Records are written to the sinks as they are parsed, and the sinks are aborted if any run
+// fails, so that a bad file never partially replaces a file, the catalog store or standard output. It returns the
+// combined stats of the runs.
+func ingest(ctx context.Context, filename string, layout parser.Layout, flagPositions []string, sinks *sink.Config, opts ...parser.Option) (parser.Stats, error) {
+	var stats parser.Stats
 
-	// As each record (or error) is generated, add the record to the results array or log the error.
-	var results []*product.Record
+	out, err := sinks.Open(filename)
+	if err != nil {
+		return stats, errors.Wrap(err, "Error opening sinks")
+	}
 
-	for {
-		select {
-		case err := <-errors:
-			log.Println(err)
-		case r := <-records:
-			fmt.Println(r)
-			results = append(results, r)
-		case <-done:
-			log.Println("Done")
-			os.Exit(0)
+	err = input.Each(filename, func(name string, r io.Reader) error {
+		p, err := getParser(r, layout, flagPositions, append(opts[:len(opts):len(opts)], parser.WithSource(name))...)
+		if err != nil {
+			return errors.Wrap(err, "Error creating parser")
+		}
+
+		// Parsing stops if a record can't be written; the remaining results are drained until the channel closes.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// As each record (or error) is generated, write the record to the sinks or log the error.
+		var writeErr error
+		for result := range p.Results(ctx) {
+			if writeErr != nil {
+				continue
+			}
+			if result.Err != nil {
+				log.Println(result.Err)
+				continue
+			}
+			if err := out.Write(ctx, result.Record); err != nil {
+				writeErr = errors.Wrapf(err, "Error writing record %d", result.Record.ID)
+				cancel()
+			}
+		}
+
+		stats = stats.Merge(p.Stats())
+		if writeErr != nil {
+			return writeErr
 		}
+		return errors.Wrapf(p.Err(), "Parsing %s failed", name)
+	})
+	if err != nil {
+		out.Abort()
+		return stats, err
 	}
+	return stats, errors.Wrap(out.Close(), "Error writing records")
 }
 
-func getParser(input io.Reader) (Parser, error) {
-	convert, err := getConverter()
+func getParser(input io.Reader, layout parser.Layout, flagPositions []string, opts ...parser.Option) (Parser, error) {
+	convert, err := getConverter(layout, flagPositions)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return parser.New(input, convert)
+	return parser.New(input, convert, append([]parser.Option{parser.WithLayout(layout)}, opts...)...)
 }
 
-func getConverter() (parser.Converter, error) {
-	return product.NewConverter(parser.NumberFieldLength, parser.CurrencyFieldLength, parser.FlagsFieldLength)
+// getConverter creates a converter for the layout's field lengths. If flagPositions is empty,
+// the default flag positions are used.
+func getConverter(layout parser.Layout, flagPositions []string) (parser.Converter, error) {
+	convert, err := product.NewConverter(
+		layout.FieldLength(parser.KindNumber),
+		layout.FieldLength(parser.KindCurrency),
+		layout.FieldLength(parser.KindFlags))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(flagPositions) > 0 {
+		if err := convert.MapFlags(flagPositions...); err != nil {
+			return nil, err
+		}
+	}
+	return convert, nil
+}
+
+// getSinks reads the sink configuration file, or if there isn't one, configures a sink for the output file
+// (or standard output) in the format, plus the catalog store if dbFile is set.
+func getSinks(configFile, format, columns, outputFile, dbFile string) (*sink.Config, error) {
+	if configFile != "" {
+		return sink.LoadConfig(configFile)
+	}
+
+	output := sink.Destination{Type: sink.TypeStdout, Format: format, Path: outputFile}
+	if outputFile != "" {
+		output.Type = sink.TypeFile
+	}
+	if columns != "" {
+		output.Columns = strings.Split(columns, ",")
+	}
+
+	c := &sink.Config{Sinks: []sink.Destination{output}}
+	if dbFile != "" {
+		c.Sinks = append(c.Sinks, sink.Destination{Type: sink.TypeStore, Path: dbFile})
+	}
+	return c, c.Validate()
+}
+
+// interruptContext returns a context that is cancelled on interrupt.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+		signal.Stop(interrupt)
+	}()
+	return ctx, cancel
+}
+
+// printStats writes a summary of a parse run.
+func printStats(w io.Writer, stats parser.Stats) {
+	fmt.Fprintf(w, "Rows read:        %d (%d bytes in %v)\n", stats.Rows, stats.Bytes, stats.Elapsed)
+	fmt.Fprintf(w, "Records:          %d\n", stats.Records)
+	fmt.Fprintf(w, "Errors:           %d\n", stats.Errors)
+	fmt.Fprintf(w, "Blank lines:      %d\n", stats.Blank)
+	fmt.Fprintf(w, "Singular price:   %d\n", stats.SingularPrice)
+	fmt.Fprintf(w, "Split price:      %d\n", stats.SplitPrice)
+	fmt.Fprintf(w, "Singular promo:   %d\n", stats.SingularPromoPrice)
+	fmt.Fprintf(w, "Split promo:      %d\n", stats.SplitPromoPrice)
+	fmt.Fprintf(w, "Taxable:          %d\n", stats.Taxable)
+	fmt.Fprintf(w, "Per weight:       %d\n", stats.PerWeight)
+
+	var units []string
+	for unit := range stats.Units {
+		units = append(units, string(unit))
+	}
+	sort.Strings(units)
+	for _, unit := range units {
+		fmt.Fprintf(w, "Unit %-12s %d\n", unit+":", stats.Units[product.UnitOfMeasure(unit)])
+	}
+
+	var normalizations []string
+	for n := range stats.Normalized {
+		normalizations = append(normalizations, string(n))
+	}
+	sort.Strings(normalizations)
+	for _, n := range normalizations {
+		fmt.Fprintf(w, "Fixed %-11s %d\n", n+":", stats.Normalized[parser.Normalization(n)])
+	}
+
+	var codes []string
+	for code := range stats.ErrorCodes {
+		codes = append(codes, string(code))
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		fmt.Fprintf(w, "Error %-11s %d\n", code+":", stats.ErrorCodes[parser.Code(code)])
+	}
 }
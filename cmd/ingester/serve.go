@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/charset"
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/jessejohnston/ProductIngester/server"
+	"github.com/jessejohnston/ProductIngester/tax"
+)
+
+// serveMain runs the HTTP ingestion service until interrupted.
+func serveMain(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	layoutFile := flags.String("layout", "", "JSON or YAML record layout file (default: standard store layout)")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of parsing workers per job")
+	maxErrors := flags.Int("max-errors", -1, "fail a job if more than this many lines fail to parse (default: no limit)")
+	maxErrorRate := flags.Float64("max-error-rate", -1, "fail a job if more than this percentage of lines fail to parse (default: no limit)")
+	flagPositions := flags.String("flags", "", "comma-separated flag names for each position of the flags field, empty to ignore a position (default: "+strings.Join(product.DefaultFlagPositions, ",")+")")
+	taxFile := flags.String("tax", "", "JSON or YAML tax table file (default: a single rate of "+parser.DefaultTaxRate.String()+")")
+	store := flags.String("store", "", "store the files are from, for the tax table")
+	region := flags.String("region", "", "region the store is in, for the tax table")
+	unitPricing := flags.String("unit-pricing", "US", "unit pricing rules of the jurisdiction, US or EU")
+	charsetName := flags.String("charset", charset.UTF8.Name(), "character encoding of the input: utf-8, windows-1252, latin-1 or ebcdic")
+	leniencyNames := flags.String("leniency", "", "comma-separated normalizations of lines that aren't the record length: pad_short and trim_long (default: none)")
+	maxUploadBytes := flags.Int64("max-upload-bytes", server.DefaultMaxUploadBytes, "largest upload accepted, in bytes as received")
+	jobTTL := flags.Duration("job-ttl", server.DefaultJobTTL, "how long a finished job is kept")
+	maxJobs := flags.Int("max-jobs", server.DefaultMaxJobs, "number of finished jobs kept")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ingester serve [options]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	layout := parser.DefaultLayout()
+	if *layoutFile != "" {
+		var err error
+		layout, err = parser.LoadLayout(*layoutFile)
+		if err != nil {
+			log.Fatalf("Error loading layout %s: %v", *layoutFile, err)
+		}
+	}
+
+	pricing, err := product.UnitPricingFor(*unitPricing)
+	if err != nil {
+		log.Fatalf("Error choosing unit pricing: %v", err)
+	}
+
+	cs, err := charset.Lookup(*charsetName)
+	if err != nil {
+		log.Fatalf("Error choosing charset: %v", err)
+	}
+
+	leniency, err := parser.ParseLeniency(strings.Split(*leniencyNames, ",")...)
+	if err != nil {
+		log.Fatalf("Error choosing leniency: %v", err)
+	}
+
+	opts := []parser.Option{parser.WithConcurrency(*workers), parser.WithUnitPricing(pricing), parser.WithCharset(cs), parser.WithLeniency(leniency)}
+	if *maxErrors >= 0 {
+		opts = append(opts, parser.WithMaxErrors(*maxErrors))
+	}
+	if *maxErrorRate >= 0 {
+		opts = append(opts, parser.WithMaxErrorRate(*maxErrorRate))
+	}
+
+	var table *tax.Table
+	if *taxFile != "" {
+		table, err = tax.LoadTable(*taxFile)
+		if err != nil {
+			log.Fatalf("Error loading tax table %s: %v", *taxFile, err)
+		}
+	}
+
+	var positions []string
+	if *flagPositions != "" {
+		positions = strings.Split(*flagPositions, ",")
+	}
+
+	// Check the parser options before serving, rather than failing every job.
+	if _, err := getParser(strings.NewReader(""), layout, positions, opts...); err != nil {
+		log.Fatalf("Error creating parser: %v", err)
+	}
+
+	handler, err := server.New(func(input io.Reader, source string, uploadOpts ...parser.Option) (server.Parser, error) {
+		jobOpts := append(opts[:len(opts):len(opts)], uploadOpts...)
+		if table != nil {
+			// Each job uses the tax rates of the day it is received.
+			jobOpts = append(jobOpts, parser.WithTaxPolicy(table.Policy(*store, *region, time.Now())))
+		}
+		return getParser(input, layout, positions, jobOpts...)
+	}, server.WithMaxUploadBytes(*maxUploadBytes), server.WithJobTTL(*jobTTL), server.WithMaxJobs(*maxJobs))
+	if err != nil {
+		log.Fatalf("Error creating server: %v", err)
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: handler}
+
+	// Stop accepting jobs on interrupt, and wait for running jobs to finish.
+	ctx, cancel := interruptContext()
+	defer cancel()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		srv.Shutdown(context.Background())
+		handler.Shutdown(context.Background())
+	}()
+
+	log.Printf("Listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatalf("Error serving: %v", err)
+	}
+	<-stopped
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/charset"
+	"github.com/jessejohnston/ProductIngester/export"
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/jessejohnston/ProductIngester/tax"
+	"github.com/jessejohnston/ProductIngester/watch"
+)
+
+// watchMain ingests each file that arrives in an inbox directory until interrupted.
+func watchMain(args []string) {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := flags.Duration("interval", watch.DefaultInterval, "time between scans of the inbox")
+	manifestFile := flags.String("manifest", "", "manifest file of the files taken from the inbox (default: <inbox>/"+watch.ManifestFile+")")
+	layoutFile := flags.String("layout", "", "JSON or YAML record layout file (default: standard store layout)")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of parsing workers")
+	maxErrors := flags.Int("max-errors", -1, "fail a file if more than this many lines fail to parse (default: no limit)")
+	maxErrorRate := flags.Float64("max-error-rate", -1, "fail a file if more than this percentage of lines fail to parse (default: no limit)")
+	stopOnError := flags.Bool("stop-on-error", false, "fail a file at the first line that fails to parse")
+	flagPositions := flags.String("flags", "", "comma-separated flag names for each position of the flags field, empty to ignore a position (default: "+strings.Join(product.DefaultFlagPositions, ",")+")")
+	taxFile := flags.String("tax", "", "JSON or YAML tax table file (default: a single rate of "+parser.DefaultTaxRate.String()+")")
+	store := flags.String("store", "", "store the files are from, for the tax table")
+	region := flags.String("region", "", "region the store is in, for the tax table")
+	unitPricing := flags.String("unit-pricing", "US", "unit pricing rules of the jurisdiction, US or EU")
+	charsetName := flags.String("charset", charset.UTF8.Name(), "character encoding of the input: utf-8, windows-1252, latin-1 or ebcdic")
+	leniencyNames := flags.String("leniency", "", "comma-separated normalizations of lines that aren't the record length: pad_short and trim_long (default: none)")
+	format := flags.String("format", export.FormatText, "output format: text, jsonl or csv")
+	columns := flags.String("columns", "", "comma-separated columns of csv output (default: "+strings.Join(export.DefaultCSVColumns, ",")+")")
+	dbFile := flags.String("db", "", "also upsert the records into the catalog store in this file")
+	sinksFile := flags.String("sinks", "", "YAML file of sinks to deliver records to, instead of -format, -columns and -db")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ingester watch [options] <inbox directory>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		flags.Usage()
+		os.Exit(1)
+	}
+	inbox := flags.Arg(0)
+
+	sinks, err := getSinks(*sinksFile, *format, *columns, "", *dbFile)
+	if err != nil {
+		log.Fatalf("Error choosing sinks: %v", err)
+	}
+
+	layout := parser.DefaultLayout()
+	if *layoutFile != "" {
+		layout, err = parser.LoadLayout(*layoutFile)
+		if err != nil {
+			log.Fatalf("Error loading layout %s: %v", *layoutFile, err)
+		}
+	}
+
+	pricing, err := product.UnitPricingFor(*unitPricing)
+	if err != nil {
+		log.Fatalf("Error choosing unit pricing: %v", err)
+	}
+
+	cs, err := charset.Lookup(*charsetName)
+	if err != nil {
+		log.Fatalf("Error choosing charset: %v", err)
+	}
+
+	leniency, err := parser.ParseLeniency(strings.Split(*leniencyNames, ",")...)
+	if err != nil {
+		log.Fatalf("Error choosing leniency: %v", err)
+	}
+
+	opts := []parser.Option{parser.WithConcurrency(*workers), parser.WithUnitPricing(pricing), parser.WithCharset(cs), parser.WithLeniency(leniency)}
+	if *stopOnError {
+		opts = append(opts, parser.WithStopOnError())
+	} else if *maxErrors >= 0 {
+		opts = append(opts, parser.WithMaxErrors(*maxErrors))
+	}
+	if *maxErrorRate >= 0 {
+		opts = append(opts, parser.WithMaxErrorRate(*maxErrorRate))
+	}
+
+	var table *tax.Table
+	if *taxFile != "" {
+		table, err = tax.LoadTable(*taxFile)
+		if err != nil {
+			log.Fatalf("Error loading tax table %s: %v", *taxFile, err)
+		}
+	}
+
+	var positions []string
+	if *flagPositions != "" {
+		positions = strings.Split(*flagPositions, ",")
+	}
+
+	// Check the parser options before watching, rather than failing every file.
+	if _, err := getParser(strings.NewReader(""), layout, positions, opts...); err != nil {
+		log.Fatalf("Error creating parser: %v", err)
+	}
+
+	ingestFile := func(ctx context.Context, filename string) (parser.Stats, error) {
+		fileOpts := opts
+		if table != nil {
+			// Each file uses the tax rates of the day it is ingested.
+			fileOpts = append(opts[:len(opts):len(opts)], parser.WithTaxPolicy(table.Policy(*store, *region, time.Now())))
+		}
+
+		log.Printf("Ingesting %s", filename)
+		stats, err := ingest(ctx, filename, layout, positions, sinks, fileOpts...)
+		if err != nil {
+			log.Printf("Failed %s: %v", filename, err)
+		} else {
+			log.Printf("Ingested %s: %d records, %d errors", filename, stats.Records, stats.Errors)
+		}
+		return stats, err
+	}
+
+	var watchOpts []watch.Option
+	watchOpts = append(watchOpts, watch.WithInterval(*interval))
+	if *manifestFile != "" {
+		watchOpts = append(watchOpts, watch.WithManifest(*manifestFile))
+	}
+
+	w, err := watch.New(inbox, ingestFile, watchOpts...)
+	if err != nil {
+		log.Fatalf("Error watching %s: %v", inbox, err)
+	}
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	log.Printf("Watching %s", inbox)
+	if err := w.Run(ctx); err != nil {
+		log.Fatalf("Error watching %s: %v", inbox, err)
+	}
+}
@@ -0,0 +1,157 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+)
+
+// Kind identifies what changed about a product between two catalogs.
+type Kind string
+
+const (
+	// KindAdded is a product that is only in the new catalog.
+	KindAdded Kind = "added"
+
+	// KindRemoved is a product that is only in the old catalog.
+	KindRemoved Kind = "removed"
+
+	// KindPriceChanged is a change of regular price, including a change between singular and split price.
+	KindPriceChanged Kind = "price_changed"
+
+	// KindPromoStarted is a product that has a promotional price in the new catalog, but not the old.
+	KindPromoStarted Kind = "promo_started"
+
+	// KindPromoEnded is a product that has a promotional price in the old catalog, but not the new.
+	KindPromoEnded Kind = "promo_ended"
+
+	// KindPromoChanged is a change of promotional price.
+	KindPromoChanged Kind = "promo_changed"
+
+	// KindDescriptionChanged is a change of description.
+	KindDescriptionChanged Kind = "description_changed"
+
+	// KindSizeChanged is a change of size.
+	KindSizeChanged Kind = "size_changed"
+
+	// KindFlagsChanged is a change of flags, such as a product becoming taxable or discontinued.
+	KindFlagsChanged Kind = "flags_changed"
+)
+
+// ErrDuplicateID is the error returned when a catalog has more than one record with the same ID.
+var ErrDuplicateID = errors.New("Duplicate product ID")
+
+// Change is a single difference between two catalogs. From and To are the display text of the old and new values;
+// an added product has no From, and a removed product no To.
+type Change struct {
+	Kind        Kind   `json:"kind"`
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	From        string `json:"from,omitempty"`
+	To          string `json:"to,omitempty"`
+
+	// Old and New are the product in each catalog, nil if it isn't in that catalog.
+	Old *product.Record `json:"-"`
+	New *product.Record `json:"-"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case KindAdded:
+		return fmt.Sprintf("+ %d %s %s", c.ID, c.Description, c.To)
+	case KindRemoved:
+		return fmt.Sprintf("- %d %s %s", c.ID, c.Description, c.From)
+	}
+	return fmt.Sprintf("~ %d %s %s: %q -> %q", c.ID, c.Description, c.Kind, c.From, c.To)
+}
+
+// Compare returns the changes from the old catalog (before) to the new one (after), matching products by ID. Changes are ordered
+// by product ID, then in the order of the Kind constants.
+func Compare(before, after []*product.Record) ([]Change, error) {
+	beforeByID, err := index(before)
+	if err != nil {
+		return nil, errors.Wrap(err, "old catalog")
+	}
+	afterByID, err := index(after)
+	if err != nil {
+		return nil, errors.Wrap(err, "new catalog")
+	}
+
+	ids := make([]int, 0, len(beforeByID)+len(afterByID))
+	for id := range beforeByID {
+		ids = append(ids, id)
+	}
+	for id := range afterByID {
+		if _, ok := beforeByID[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	var changes []Change
+	for _, id := range ids {
+		changes = append(changes, Records(beforeByID[id], afterByID[id])...)
+	}
+	return changes, nil
+}
+
+// Records returns the changes from the old record (before) to the new record (after) of the same product. Either record may be nil.
+func Records(before, after *product.Record) []Change {
+	switch {
+	case before == nil && after == nil:
+		return nil
+	case before == nil:
+		return []Change{{Kind: KindAdded, ID: after.ID, Description: after.Description, To: after.DisplayPrice, New: after}}
+	case after == nil:
+		return []Change{{Kind: KindRemoved, ID: before.ID, Description: before.Description, From: before.DisplayPrice, Old: before}}
+	}
+
+	var changes []Change
+	add := func(kind Kind, from, to string) {
+		changes = append(changes, Change{Kind: kind, ID: after.ID, Description: after.Description, From: from, To: to, Old: before, New: after})
+	}
+
+	if !before.Price.Equal(after.Price) || before.SplitQuantity != after.SplitQuantity || !before.SplitPrice.Equal(after.SplitPrice) {
+		add(KindPriceChanged, before.DisplayPrice, after.DisplayPrice)
+	}
+
+	beforePromo, afterPromo := !before.PromoPrice.IsZero(), !after.PromoPrice.IsZero()
+	switch {
+	case !beforePromo && afterPromo:
+		add(KindPromoStarted, "", after.PromoDisplayPrice)
+	case beforePromo && !afterPromo:
+		add(KindPromoEnded, before.PromoDisplayPrice, "")
+	case beforePromo && (!before.PromoPrice.Equal(after.PromoPrice) || before.PromoSplitQuantity != after.PromoSplitQuantity ||
+		!before.PromoSplitPrice.Equal(after.PromoSplitPrice)):
+		add(KindPromoChanged, before.PromoDisplayPrice, after.PromoDisplayPrice)
+	}
+
+	if before.Description != after.Description {
+		add(KindDescriptionChanged, before.Description, after.Description)
+	}
+	if before.Size != after.Size {
+		add(KindSizeChanged, before.Size, after.Size)
+	}
+	if before.Flags != after.Flags {
+		add(KindFlagsChanged, before.Flags.String(), after.Flags.String())
+	}
+
+	return changes
+}
+
+// index returns the records of a catalog by ID.
+func index(records []*product.Record) (map[int]*product.Record, error) {
+	byID := make(map[int]*product.Record, len(records))
+	for _, r := range records {
+		if r == nil {
+			continue
+		}
+		if _, ok := byID[r.ID]; ok {
+			return nil, errors.Wrapf(ErrDuplicateID, "product %d", r.ID)
+		}
+		byID[r.ID] = r
+	}
+	return byID, nil
+}
@@ -0,0 +1,120 @@
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type diffTestSuite struct {
+	suite.Suite
+}
+
+func Test_Diff(t *testing.T) {
+	s := new(diffTestSuite)
+	suite.Run(t, s)
+}
+
+func record(id int, description string, cents int64) *product.Record {
+	price := decimal.New(cents, -2)
+	return &product.Record{
+		ID:                id,
+		Description:       description,
+		Price:             price,
+		DisplayPrice:      product.FormatPrice(price, decimal.Zero, 0),
+		PromoDisplayPrice: "$0.00",
+	}
+}
+
+func kinds(changes []Change) []Kind {
+	var k []Kind
+	for _, c := range changes {
+		k = append(k, c.Kind)
+	}
+	return k
+}
+
+func (s *diffTestSuite) Test_Compare_SameCatalog_ReturnsNoChanges() {
+	catalog := []*product.Record{record(1, "Rice", 567), record(2, "Soda", 650)}
+	changes, err := Compare(catalog, catalog)
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), changes)
+}
+
+func (s *diffTestSuite) Test_Compare_AddedAndRemoved_ReturnsChangesByID() {
+	before := []*product.Record{record(3, "Cigarettes", 1000), record(1, "Rice", 567)}
+	after := []*product.Record{record(1, "Rice", 567), record(2, "Soda", 650)}
+
+	changes, err := Compare(before, after)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []Kind{KindAdded, KindRemoved}, kinds(changes))
+	require.Equal(s.T(), 2, changes[0].ID)
+	require.Equal(s.T(), "$6.50", changes[0].To)
+	require.Equal(s.T(), 3, changes[1].ID)
+	require.Equal(s.T(), "$10.00", changes[1].From)
+	require.Nil(s.T(), changes[1].New)
+}
+
+func (s *diffTestSuite) Test_Records_PriceChanged_ReturnsDisplayPrices() {
+	changes := Records(record(1, "Rice", 567), record(1, "Rice", 599))
+	require.Equal(s.T(), []Kind{KindPriceChanged}, kinds(changes))
+	require.Equal(s.T(), "$5.67", changes[0].From)
+	require.Equal(s.T(), "$5.99", changes[0].To)
+}
+
+func (s *diffTestSuite) Test_Records_SplitDealChanged_ReturnsPriceChanged() {
+	before := record(2, "Soda", 650)
+	before.SplitPrice, before.SplitQuantity, before.DisplayPrice = decimal.New(13, 0), 2, "2/$13.00"
+	after := record(2, "Soda", 650)
+
+	changes := Records(before, after)
+	require.Equal(s.T(), []Kind{KindPriceChanged}, kinds(changes))
+	require.Equal(s.T(), "2/$13.00", changes[0].From)
+	require.Equal(s.T(), "$6.50", changes[0].To)
+}
+
+func (s *diffTestSuite) Test_Records_Promos_ReturnStartChangeAndEnd() {
+	none := record(1, "Rice", 567)
+	promo := record(1, "Rice", 567)
+	promo.PromoPrice, promo.PromoDisplayPrice = decimal.New(499, -2), "$4.99"
+	other := record(1, "Rice", 567)
+	other.PromoPrice, other.PromoDisplayPrice = decimal.New(449, -2), "$4.49"
+
+	require.Equal(s.T(), []Kind{KindPromoStarted}, kinds(Records(none, promo)))
+	require.Equal(s.T(), []Kind{KindPromoEnded}, kinds(Records(promo, none)))
+	require.Equal(s.T(), []Kind{KindPromoChanged}, kinds(Records(promo, other)))
+	require.Empty(s.T(), Records(promo, promo))
+}
+
+func (s *diffTestSuite) Test_Records_Edits_ReturnEachChange() {
+	before := record(1, "Rice", 567)
+	after := record(1, "Kimchi Rice", 567)
+	after.Size = "18oz"
+	after.Flags = product.FlagDiscontinued
+
+	changes := Records(before, after)
+	require.Equal(s.T(), []Kind{KindDescriptionChanged, KindSizeChanged, KindFlagsChanged}, kinds(changes))
+	require.Equal(s.T(), "discontinued", changes[2].To)
+}
+
+func (s *diffTestSuite) Test_Compare_DuplicateID_ReturnsError() {
+	_, err := Compare([]*product.Record{record(1, "Rice", 567), record(1, "Rice", 599)}, nil)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrDuplicateID, errors.Cause(err))
+}
+
+func (s *diffTestSuite) Test_Change_JSON_LeavesOutRecords() {
+	data, err := json.Marshal(Records(record(1, "Rice", 567), record(1, "Rice", 599))[0])
+	require.NoError(s.T(), err)
+	require.JSONEq(s.T(), `{"kind":"price_changed","id":1,"description":"Rice","from":"$5.67","to":"$5.99"}`, string(data))
+}
+
+func (s *diffTestSuite) Test_Change_String_DescribesChange() {
+	require.Equal(s.T(), `~ 1 Rice price_changed: "$5.67" -> "$5.99"`, Records(record(1, "Rice", 567), record(1, "Rice", 599))[0].String())
+	require.Equal(s.T(), "+ 1 Rice $5.67", Records(nil, record(1, "Rice", 567))[0].String())
+}
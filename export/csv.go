@@ -0,0 +1,128 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultCSVColumns are the columns written by a CSVWriter created without columns.
+var DefaultCSVColumns = []string{
+	"id",
+	"description",
+	"display_price",
+	"price",
+	"promo_display_price",
+	"promo_price",
+	"unit",
+	"size",
+	"tax_rate",
+	"flags",
+}
+
+// csvColumns are the values of each column a CSVWriter can write, named as in the JSON form of a record.
+var csvColumns = map[string]func(r *product.Record) string{
+	"id":                   func(r *product.Record) string { return strconv.Itoa(r.ID) },
+	"description":          func(r *product.Record) string { return r.Description },
+	"display_price":        func(r *product.Record) string { return r.DisplayPrice },
+	"price":                func(r *product.Record) string { return r.Price.String() },
+	"promo_display_price":  func(r *product.Record) string { return r.PromoDisplayPrice },
+	"promo_price":          func(r *product.Record) string { return r.PromoPrice.String() },
+	"unit":                 func(r *product.Record) string { return string(r.Unit) },
+	"size":                 func(r *product.Record) string { return r.Size },
+	"tax_rate":             func(r *product.Record) string { return r.TaxRate.String() },
+	"flags":                func(r *product.Record) string { return r.Flags.String() },
+	"quantity":             func(r *product.Record) string { return r.Quantity.String() },
+	"unit_price":           func(r *product.Record) string { return optional(r.UnitPrice, !r.UnitPriceBasis.IsZero()) },
+	"promo_unit_price":     func(r *product.Record) string { return optional(r.PromoUnitPrice, !r.UnitPriceBasis.IsZero()) },
+	"unit_price_basis":     func(r *product.Record) string { return r.UnitPriceBasis.String() },
+	"split_price":          func(r *product.Record) string { return optional(r.SplitPrice, r.IsSplitPrice()) },
+	"split_quantity":       func(r *product.Record) string { return strconv.Itoa(r.SplitQuantity) },
+	"promo_split_price":    func(r *product.Record) string { return optional(r.PromoSplitPrice, r.IsSplitPromoPrice()) },
+	"promo_split_quantity": func(r *product.Record) string { return strconv.Itoa(r.PromoSplitQuantity) },
+}
+
+// optional returns the text of a decimal the record has, or "" if it doesn't.
+func optional(d decimal.Decimal, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return d.String()
+}
+
+// CheckCSVColumns returns an error if any of the columns is unknown or repeated.
+func CheckCSVColumns(columns ...string) error {
+	seen := make(map[string]bool)
+	for _, c := range columns {
+		if _, ok := csvColumns[c]; !ok {
+			return errors.Wrapf(ErrBadParameter, "unknown column %q", c)
+		}
+		if seen[c] {
+			return errors.Wrapf(ErrBadParameter, "duplicate column %q", c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// CSVWriter writes records as CSV, with a header row of column names. Fields are quoted as described by RFC 4180.
+type CSVWriter struct {
+	dst     *csv.Writer
+	columns []string
+	header  bool
+}
+
+// NewCSVWriter creates a writer of the given columns, in order, or of DefaultCSVColumns if there are none.
+func NewCSVWriter(output io.Writer, columns ...string) (*CSVWriter, error) {
+	if output == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+	if err := CheckCSVColumns(columns...); err != nil {
+		return nil, err
+	}
+
+	return &CSVWriter{
+		dst:     csv.NewWriter(output),
+		columns: append([]string(nil), columns...),
+	}, nil
+}
+
+// Write writes a record as a CSV row, after the header row if it hasn't been written.
+func (w *CSVWriter) Write(r *product.Record) error {
+	if r == nil {
+		return errors.WithStack(ErrBadParameter)
+	}
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+
+	row := make([]string, len(w.columns))
+	for i, c := range w.columns {
+		row[i] = csvColumns[c](r)
+	}
+	return errors.WithStack(w.dst.Write(row))
+}
+
+// Flush writes any buffered rows to the output. The header row is written even if there are no records.
+func (w *CSVWriter) Flush() error {
+	if err := w.writeHeader(); err != nil {
+		return err
+	}
+	w.dst.Flush()
+	return errors.WithStack(w.dst.Error())
+}
+
+func (w *CSVWriter) writeHeader() error {
+	if w.header {
+		return nil
+	}
+	w.header = true
+	return errors.WithStack(w.dst.Write(w.columns))
+}
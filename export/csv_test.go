@@ -0,0 +1,89 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type csvTestSuite struct {
+	suite.Suite
+	record *product.Record
+}
+
+func Test_CSV(t *testing.T) {
+	s := new(csvTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *csvTestSuite) SetupTest() {
+	s.record = &product.Record{
+		ID:                50133333,
+		Description:       `Fuji Apples (Organic), "Extra Fancy"`,
+		DisplayPrice:      "$3.49",
+		Price:             decimal.New(349, -2),
+		PromoDisplayPrice: "$0.00",
+		Unit:              product.UnitPound,
+		Size:              "lb",
+		Flags:             product.FlagPerWeight | product.FlagOrganic,
+	}
+}
+
+func (s *csvTestSuite) Test_Write_DefaultColumns_WritesHeaderAndQuotedRow() {
+	var output bytes.Buffer
+	w, err := NewWriter(FormatCSV, &output)
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), w.Write(s.record))
+	require.NoError(s.T(), w.Flush())
+
+	require.Equal(s.T(),
+		"id,description,display_price,price,promo_display_price,promo_price,unit,size,tax_rate,flags\n"+
+			`50133333,"Fuji Apples (Organic), ""Extra Fancy""",$3.49,3.49,$0.00,0,Pound,lb,0,"per_weight,organic"`+"\n",
+		output.String())
+}
+
+func (s *csvTestSuite) Test_Write_SelectedColumns_WritesColumnsInOrder() {
+	var output bytes.Buffer
+	w, err := NewCSVWriter(&output, "price", "id", "split_price", "split_quantity")
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), w.Write(s.record))
+	s.record.SplitPrice = decimal.New(7, 0)
+	s.record.SplitQuantity = 2
+	require.NoError(s.T(), w.Write(s.record))
+	require.NoError(s.T(), w.Flush())
+
+	rows, err := csv.NewReader(&output).ReadAll()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), [][]string{
+		{"price", "id", "split_price", "split_quantity"},
+		{"3.49", "50133333", "", "0"},
+		{"3.49", "50133333", "7", "2"},
+	}, rows)
+}
+
+func (s *csvTestSuite) Test_Flush_NoRecords_WritesHeader() {
+	var output bytes.Buffer
+	w, _ := NewCSVWriter(&output, "id", "description")
+	require.NoError(s.T(), w.Flush())
+	require.Equal(s.T(), "id,description\n", output.String())
+}
+
+func (s *csvTestSuite) Test_NewCSVWriter_UnknownColumn_ReturnsError() {
+	_, err := NewCSVWriter(&bytes.Buffer{}, "id", "color")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *csvTestSuite) Test_NewCSVWriter_DuplicateColumn_ReturnsError() {
+	_, err := NewCSVWriter(&bytes.Buffer{}, "id", "id")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
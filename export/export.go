@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+)
+
+// Export formats accepted by NewWriter.
+const (
+	// FormatText writes each record with Record.String.
+	FormatText = "text"
+
+	// FormatJSONLines writes each record as a JSON object on its own line.
+	FormatJSONLines = "jsonl"
+
+	// FormatCSV writes each record as a CSV row of DefaultCSVColumns.
+	FormatCSV = "csv"
+)
+
+// ErrBadParameter is the error returned when invalid input is provided.
+var ErrBadParameter = errors.New("Invalid parameter")
+
+// Writer is the behavior of a type that writes product records in an export format.
+// Output may be buffered until Flush is called.
+type Writer interface {
+	Write(r *product.Record) error
+	Flush() error
+}
+
+// IsFormat returns true if format is accepted by NewWriter.
+func IsFormat(format string) bool {
+	switch format {
+	case FormatText, FormatJSONLines, FormatCSV:
+		return true
+	}
+	return false
+}
+
+// NewWriter creates a writer of the given format.
+func NewWriter(format string, output io.Writer) (Writer, error) {
+	switch format {
+	case FormatText:
+		return NewTextWriter(output)
+	case FormatJSONLines:
+		return NewJSONLinesWriter(output)
+	case FormatCSV:
+		return NewCSVWriter(output)
+	}
+	return nil, errors.Wrapf(ErrBadParameter, "unknown export format %q", format)
+}
+
+// TextWriter writes records in the fixed-width display format of Record.String.
+type TextWriter struct {
+	dst *bufio.Writer
+}
+
+// NewTextWriter creates a writer of records as display text.
+func NewTextWriter(output io.Writer) (*TextWriter, error) {
+	if output == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+	return &TextWriter{dst: bufio.NewWriter(output)}, nil
+}
+
+// Write writes a record as a line of text.
+func (w *TextWriter) Write(r *product.Record) error {
+	if r == nil {
+		return errors.WithStack(ErrBadParameter)
+	}
+	_, err := fmt.Fprintln(w.dst, r)
+	return errors.WithStack(err)
+}
+
+// Flush writes any buffered records to the output.
+func (w *TextWriter) Flush() error {
+	return errors.WithStack(w.dst.Flush())
+}
+
+// JSONLinesWriter writes records as JSON Lines: one JSON object per line.
+type JSONLinesWriter struct {
+	dst     *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewJSONLinesWriter creates a writer of records as JSON Lines.
+func NewJSONLinesWriter(output io.Writer) (*JSONLinesWriter, error) {
+	if output == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+
+	dst := bufio.NewWriter(output)
+	encoder := json.NewEncoder(dst)
+	encoder.SetEscapeHTML(false)
+
+	return &JSONLinesWriter{dst: dst, encoder: encoder}, nil
+}
+
+// Write writes a record as a line of JSON.
+func (w *JSONLinesWriter) Write(r *product.Record) error {
+	if r == nil {
+		return errors.WithStack(ErrBadParameter)
+	}
+	return errors.WithStack(w.encoder.Encode(r))
+}
+
+// Flush writes any buffered records to the output.
+func (w *JSONLinesWriter) Flush() error {
+	return errors.WithStack(w.dst.Flush())
+}
@@ -0,0 +1,118 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type exportTestSuite struct {
+	suite.Suite
+	records []*product.Record
+}
+
+func Test_Export(t *testing.T) {
+	s := new(exportTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *exportTestSuite) SetupTest() {
+	q, _ := product.ParseSize("lb")
+	apples := &product.Record{
+		ID:                50133333,
+		Description:       "Fuji Apples (Organic)",
+		DisplayPrice:      "$3.49",
+		Price:             decimal.New(349, -2),
+		PromoDisplayPrice: "$0.00",
+		Unit:              product.UnitPound,
+		Size:              "lb",
+		Flags:             product.FlagPerWeight,
+		Quantity:          q,
+	}
+	require.NoError(s.T(), apples.SetUnitPrices(product.UnitPricingUS))
+
+	soda := &product.Record{
+		ID:                14963801,
+		Description:       "Generic Soda 12-pack",
+		DisplayPrice:      "2/$13.00",
+		Price:             decimal.New(65, -1),
+		PromoDisplayPrice: "$5.49",
+		PromoPrice:        decimal.New(549, -2),
+		Unit:              product.UnitEach,
+		TaxRate:           decimal.New(7775, -5),
+		Flags:             product.FlagTaxable,
+		SplitPrice:        decimal.New(1300, -2),
+		SplitQuantity:     2,
+	}
+
+	s.records = []*product.Record{apples, soda}
+}
+
+func (s *exportTestSuite) Test_NewWriter_UnknownFormat_ReturnsError() {
+	_, err := NewWriter("xml", &bytes.Buffer{})
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+	require.False(s.T(), IsFormat("xml"))
+}
+
+func (s *exportTestSuite) Test_TextWriter_WritesRecordStrings() {
+	var output bytes.Buffer
+	w, err := NewWriter(FormatText, &output)
+	require.NoError(s.T(), err)
+
+	for _, r := range s.records {
+		require.NoError(s.T(), w.Write(r))
+	}
+	require.NoError(s.T(), w.Flush())
+
+	require.Equal(s.T(), s.records[0].String()+"\n"+s.records[1].String()+"\n", output.String())
+}
+
+func (s *exportTestSuite) Test_JSONLinesWriter_WritesObjectPerLine() {
+	var output bytes.Buffer
+	w, err := NewWriter(FormatJSONLines, &output)
+	require.NoError(s.T(), err)
+
+	for _, r := range s.records {
+		require.NoError(s.T(), w.Write(r))
+	}
+	require.NoError(s.T(), w.Flush())
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	require.Len(s.T(), lines, 2)
+	require.JSONEq(s.T(), `{
+		"id": 50133333,
+		"description": "Fuji Apples (Organic)",
+		"display_price": "$3.49",
+		"price": "3.49",
+		"promo_display_price": "$0.00",
+		"promo_price": "0",
+		"unit": "Pound",
+		"size": "lb",
+		"tax_rate": "0",
+		"flags": ["per_weight"],
+		"quantity": {"pack": 1, "amount": "1", "unit": "Pound"},
+		"unit_price": "0.218",
+		"promo_unit_price": "0",
+		"unit_price_basis": {"amount": "1", "unit": "Ounce"}
+	}`, lines[0])
+
+	var r product.Record
+	require.NoError(s.T(), json.Unmarshal([]byte(lines[1]), &r))
+	require.Equal(s.T(), 2, r.SplitQuantity)
+	require.True(s.T(), r.SplitPrice.Equal(decimal.New(13, 0)))
+	require.True(s.T(), r.TaxRate.Equal(decimal.New(7775, -5)))
+	require.Equal(s.T(), product.FlagTaxable, r.Flags)
+}
+
+func (s *exportTestSuite) Test_JSONLinesWriter_NilRecord_ReturnsError() {
+	w, _ := NewJSONLinesWriter(&bytes.Buffer{})
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(w.Write(nil)))
+}
@@ -0,0 +1,176 @@
+package input
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Format is the compression of an input, identified by its first bytes.
+type Format string
+
+const (
+	// FormatPlain is uncompressed input.
+	FormatPlain Format = "plain"
+
+	// FormatGzip is gzip compressed input, such as a .gz file.
+	FormatGzip Format = "gzip"
+
+	// FormatZstd is Zstandard compressed input, such as a .zst file.
+	FormatZstd Format = "zstd"
+
+	// FormatBzip2 is bzip2 compressed input, such as a .bz2 file.
+	FormatBzip2 Format = "bzip2"
+
+	// FormatZip is a zip archive of files.
+	FormatZip Format = "zip"
+)
+
+var (
+	// ErrBadParameter is the error returned when invalid input is provided.
+	ErrBadParameter = errors.New("Invalid parameter")
+
+	// ErrArchive is the error returned when a stream is a zip archive, which can only be read from a file.
+	ErrArchive = errors.New("Zip archive needs random access")
+)
+
+// magic is the first bytes of each compressed format.
+var magic = []struct {
+	format Format
+	prefix []byte
+}{
+	{FormatGzip, []byte{0x1f, 0x8b}},
+	{FormatZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{FormatBzip2, []byte("BZh")},
+	{FormatZip, []byte("PK\x03\x04")},
+	{FormatZip, []byte("PK\x05\x06")},
+}
+
+// Detect returns the format of an input from its first four bytes. Input that isn't a known compressed format
+// is plain.
+func Detect(header []byte) Format {
+	for _, m := range magic {
+		if !bytes.HasPrefix(header, m.prefix) {
+			continue
+		}
+		// A bzip2 header ends with the block size, 1-9.
+		if m.format == FormatBzip2 && (len(header) < 4 || header[3] < '1' || header[3] > '9') {
+			continue
+		}
+		return m.format
+	}
+	return FormatPlain
+}
+
+// NewReader returns a reader of the decompressed content of r, detecting its format from its first bytes.
+// A zip archive returns ErrArchive; use Each to read the files in one.
+func NewReader(r io.Reader) (io.ReadCloser, Format, error) {
+	if r == nil {
+		return nil, "", errors.WithStack(ErrBadParameter)
+	}
+
+	buffered := bufio.NewReader(r)
+
+	// Input shorter than any magic number is plain.
+	header, err := buffered.Peek(4)
+	if err != nil && err != io.EOF {
+		return nil, "", errors.WithStack(err)
+	}
+
+	format := Detect(header)
+	switch format {
+	case FormatGzip:
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, format, errors.WithStack(err)
+		}
+		return gz, format, nil
+	case FormatZstd:
+		zr, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, format, errors.WithStack(err)
+		}
+		return zr.IOReadCloser(), format, nil
+	case FormatBzip2:
+		return ioutil.NopCloser(bzip2.NewReader(buffered)), format, nil
+	case FormatZip:
+		return nil, format, errors.WithStack(ErrArchive)
+	}
+	return ioutil.NopCloser(buffered), format, nil
+}
+
+// Each calls fn with the name and decompressed content of each catalog in a file. A zip archive's catalogs are its
+// files, in archive order, named <archive>/<file>; any other file is a single catalog named filename. Each stops at
+// the first error fn returns.
+func Each(filename string, fn func(name string, r io.Reader) error) error {
+	if fn == nil {
+		return errors.WithStack(ErrBadParameter)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	r, format, err := NewReader(file)
+	if format == FormatZip {
+		return eachMember(file, filename, fn)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", filename)
+	}
+	defer r.Close()
+
+	return fn(filename, r)
+}
+
+// eachMember calls fn with each file in a zip archive.
+func eachMember(file *os.File, filename string, fn func(name string, r io.Reader) error) error {
+	info, err := file.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	archive, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", filename)
+	}
+
+	for _, member := range archive.File {
+		if member.FileInfo().IsDir() {
+			continue
+		}
+		name := filename + "/" + strings.TrimPrefix(member.Name, "/")
+		if err := readMember(member, name, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readMember calls fn with the content of a zip archive's file, which may itself be compressed.
+func readMember(member *zip.File, name string, fn func(name string, r io.Reader) error) error {
+	content, err := member.Open()
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", name)
+	}
+	defer content.Close()
+
+	r, _, err := NewReader(content)
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", name)
+	}
+	defer r.Close()
+
+	return fn(name, r)
+}
@@ -0,0 +1,186 @@
+package input
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type inputTestSuite struct {
+	suite.Suite
+	dir    string
+	sample []byte
+}
+
+func Test_Input(t *testing.T) {
+	s := new(inputTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *inputTestSuite) SetupTest() {
+	var err error
+	s.sample, err = ioutil.ReadFile("../cmd/ingester/input-sample.txt")
+	require.NoError(s.T(), err)
+
+	s.dir, err = ioutil.TempDir("", "input")
+	require.NoError(s.T(), err)
+}
+
+func (s *inputTestSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *inputTestSuite) gzipped(data []byte) []byte {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	w.Write(data)
+	require.NoError(s.T(), w.Close())
+	return b.Bytes()
+}
+
+func (s *inputTestSuite) zstded(data []byte) []byte {
+	var b bytes.Buffer
+	w, err := zstd.NewWriter(&b)
+	require.NoError(s.T(), err)
+	w.Write(data)
+	require.NoError(s.T(), w.Close())
+	return b.Bytes()
+}
+
+// zipped writes a zip archive of the members, by name.
+func (s *inputTestSuite) zipped(members ...string) string {
+	filename := filepath.Join(s.dir, "catalogs.zip")
+	file, err := os.Create(filename)
+	require.NoError(s.T(), err)
+	defer file.Close()
+
+	w := zip.NewWriter(file)
+	for i := 0; i < len(members); i += 2 {
+		m, err := w.Create(members[i])
+		require.NoError(s.T(), err)
+		m.Write([]byte(members[i+1]))
+	}
+	require.NoError(s.T(), w.Close())
+	return filename
+}
+
+// read returns the decompressed content of data.
+func (s *inputTestSuite) read(data []byte) (string, Format) {
+	r, format, err := NewReader(bytes.NewReader(data))
+	require.NoError(s.T(), err)
+	defer r.Close()
+
+	content, err := ioutil.ReadAll(r)
+	require.NoError(s.T(), err)
+	return string(content), format
+}
+
+// each returns the names and content of the catalogs in a file.
+func (s *inputTestSuite) each(filename string) ([]string, []string) {
+	var names, contents []string
+	err := Each(filename, func(name string, r io.Reader) error {
+		content, err := ioutil.ReadAll(r)
+		names = append(names, name)
+		contents = append(contents, string(content))
+		return err
+	})
+	require.NoError(s.T(), err)
+	return names, contents
+}
+
+func (s *inputTestSuite) Test_Detect_ReturnsFormat() {
+	require.Equal(s.T(), FormatGzip, Detect([]byte{0x1f, 0x8b, 8, 0}))
+	require.Equal(s.T(), FormatZstd, Detect([]byte{0x28, 0xb5, 0x2f, 0xfd}))
+	require.Equal(s.T(), FormatBzip2, Detect([]byte("BZh9")))
+	require.Equal(s.T(), FormatZip, Detect([]byte("PK\x03\x04")))
+	require.Equal(s.T(), FormatPlain, Detect([]byte("8000")))
+	require.Equal(s.T(), FormatPlain, Detect([]byte("BZhx")))
+	require.Equal(s.T(), FormatPlain, Detect(nil))
+}
+
+func (s *inputTestSuite) Test_NewReader_Plain_ReturnsInput() {
+	content, format := s.read(s.sample)
+	require.Equal(s.T(), FormatPlain, format)
+	require.Equal(s.T(), string(s.sample), content)
+}
+
+func (s *inputTestSuite) Test_NewReader_Short_IsPlain() {
+	content, format := s.read([]byte("1\n"))
+	require.Equal(s.T(), FormatPlain, format)
+	require.Equal(s.T(), "1\n", content)
+}
+
+func (s *inputTestSuite) Test_NewReader_Gzip_Decompresses() {
+	content, format := s.read(s.gzipped(s.sample))
+	require.Equal(s.T(), FormatGzip, format)
+	require.Equal(s.T(), string(s.sample), content)
+}
+
+func (s *inputTestSuite) Test_NewReader_Zstd_Decompresses() {
+	content, format := s.read(s.zstded(s.sample))
+	require.Equal(s.T(), FormatZstd, format)
+	require.Equal(s.T(), string(s.sample), content)
+}
+
+func (s *inputTestSuite) Test_NewReader_Bzip2_Decompresses() {
+	data, err := ioutil.ReadFile("testdata/input-sample.txt.bz2")
+	require.NoError(s.T(), err)
+
+	content, format := s.read(data)
+	require.Equal(s.T(), FormatBzip2, format)
+	require.Equal(s.T(), string(s.sample), content)
+}
+
+func (s *inputTestSuite) Test_NewReader_Zip_ReturnsError() {
+	data, err := ioutil.ReadFile(s.zipped("monday.txt", "rice"))
+	require.NoError(s.T(), err)
+
+	_, format, err := NewReader(bytes.NewReader(data))
+	require.Equal(s.T(), FormatZip, format)
+	require.Equal(s.T(), ErrArchive, errors.Cause(err))
+}
+
+func (s *inputTestSuite) Test_Each_GzipFile_IsOneCatalog() {
+	filename := filepath.Join(s.dir, "monday.txt.gz")
+	require.NoError(s.T(), ioutil.WriteFile(filename, s.gzipped(s.sample), 0644))
+
+	names, contents := s.each(filename)
+	require.Equal(s.T(), []string{filename}, names)
+	require.Equal(s.T(), []string{string(s.sample)}, contents)
+}
+
+func (s *inputTestSuite) Test_Each_Zip_ReadsEveryMember() {
+	filename := s.zipped("monday.txt", "rice", "old/", "", "tuesday.txt.gz", string(s.gzipped([]byte("soda"))))
+
+	names, contents := s.each(filename)
+	require.Equal(s.T(), []string{filename + "/monday.txt", filename + "/tuesday.txt.gz"}, names)
+	require.Equal(s.T(), []string{"rice", "soda"}, contents)
+}
+
+func (s *inputTestSuite) Test_Each_Error_Stops() {
+	filename := s.zipped("monday.txt", "rice", "tuesday.txt", "soda")
+	stop := errors.New("stop")
+
+	var names []string
+	err := Each(filename, func(name string, r io.Reader) error {
+		names = append(names, name)
+		return stop
+	})
+	require.Equal(s.T(), stop, errors.Cause(err))
+	require.Len(s.T(), names, 1)
+}
+
+func (s *inputTestSuite) Test_Each_Missing_ReturnsError() {
+	err := Each(filepath.Join(s.dir, "missing.txt"), func(string, io.Reader) error { return nil })
+	require.True(s.T(), os.IsNotExist(errors.Cause(err)))
+}
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrTooManyErrors is the error returned when a parse run exceeds its error budget.
+	ErrTooManyErrors = errors.New("Too many errors")
+)
+
+// budget limits the number of lines that may fail to parse in a run. Negative limits are disabled.
+type budget struct {
+	maxErrors int
+	maxRate   float64
+}
+
+// check returns ErrTooManyErrors if failed of rows exceeds the budget.
+// The error rate is only checked for the final count, as early rows aren't representative of the whole input.
+func (b budget) check(rows, failed int, final bool) error {
+	if b.maxErrors >= 0 && failed > b.maxErrors {
+		return errors.Wrapf(ErrTooManyErrors, "%d of %d rows failed, limit is %d", failed, rows, b.maxErrors)
+	}
+
+	if final && b.maxRate >= 0 && rows > 0 {
+		rate := 100 * float64(failed) / float64(rows)
+		if rate > b.maxRate {
+			return errors.Wrapf(ErrTooManyErrors, "%d of %d rows (%.2f%%) failed, limit is %.2f%%", failed, rows, rate, b.maxRate)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+const (
+	goodLine = "80000001 Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz\n"
+	badLine  = "40123401 Marlboro Cigare\n"
+)
+
+type budgetTestSuite struct {
+	suite.Suite
+	converter Converter
+}
+
+func Test_Budget(t *testing.T) {
+	s := new(budgetTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *budgetTestSuite) SetupSuite() {
+	s.converter, _ = product.NewConverter(NumberFieldLength, CurrencyFieldLength, FlagsFieldLength)
+}
+
+// parse runs the parser to completion, returning the number of results produced.
+func (s *budgetTestSuite) parse(input string, opts ...Option) (int, error) {
+	p, err := New(strings.NewReader(input), s.converter, opts...)
+	require.NoError(s.T(), err)
+
+	count := 0
+	for range p.Results(context.Background()) {
+		count++
+	}
+	return count, p.Err()
+}
+
+func (s *budgetTestSuite) Test_NewParser_BadLimits_ReturnsError() {
+	t := s.T()
+
+	_, err := New(strings.NewReader("the file"), s.converter, WithMaxErrors(-1))
+	require.Equal(t, ErrBadParameter, errors.Cause(err))
+
+	_, err = New(strings.NewReader("the file"), s.converter, WithMaxErrorRate(101))
+	require.Equal(t, ErrBadParameter, errors.Cause(err))
+}
+
+func (s *budgetTestSuite) Test_NoBudget_AllBadLines_Succeeds() {
+	count, err := s.parse(badLine + badLine + badLine)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 3, count)
+}
+
+func (s *budgetTestSuite) Test_StopOnError_StopsAtFirstError() {
+	t := s.T()
+
+	count, err := s.parse(goodLine+badLine+goodLine+goodLine, WithStopOnError(), WithBatchSize(1))
+	require.Error(t, err)
+	require.Equal(t, ErrTooManyErrors, errors.Cause(err))
+	require.Equal(t, 2, count)
+}
+
+func (s *budgetTestSuite) Test_MaxErrors_WithinLimit_Succeeds() {
+	_, err := s.parse(goodLine+badLine+goodLine+badLine, WithMaxErrors(2))
+	require.NoError(s.T(), err)
+}
+
+func (s *budgetTestSuite) Test_MaxErrors_OverLimit_StopsEarly() {
+	t := s.T()
+
+	count, err := s.parse(badLine+badLine+badLine+goodLine+goodLine, WithMaxErrors(2), WithBatchSize(1))
+	require.Equal(t, ErrTooManyErrors, errors.Cause(err))
+	require.Equal(t, 3, count)
+	require.Contains(t, err.Error(), "3 of 3 rows failed")
+}
+
+func (s *budgetTestSuite) Test_MaxErrorRate_WithinLimit_Succeeds() {
+	_, err := s.parse(badLine+goodLine+goodLine+goodLine, WithMaxErrorRate(25))
+	require.NoError(s.T(), err)
+}
+
+func (s *budgetTestSuite) Test_MaxErrorRate_OverLimit_ReturnsError() {
+	t := s.T()
+
+	count, err := s.parse(badLine+badLine+goodLine+goodLine, WithMaxErrorRate(25))
+	require.Equal(t, ErrTooManyErrors, errors.Cause(err))
+	require.Equal(t, 4, count)
+}
+
+func (s *budgetTestSuite) Test_StopOnError_CompressedInput_LeavesInputOpen() {
+	t := s.T()
+
+	var compressed bytes.Buffer
+	w, err := zstd.NewWriter(&compressed)
+	require.NoError(t, err)
+	_, err = w.Write([]byte(strings.Repeat(badLine, 10000)))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	// The decoder is still being read when the budget stops the run, so closing it then would race with the read.
+	decoder, err := zstd.NewReader(&compressed)
+	require.NoError(t, err)
+	input := decoder.IOReadCloser()
+
+	p, err := New(input, s.converter, WithStopOnError(), WithBatchSize(1))
+	require.NoError(t, err)
+	for range p.Results(context.Background()) {
+	}
+	require.Equal(t, ErrTooManyErrors, errors.Cause(p.Err()))
+	require.NoError(t, input.Close())
+}
@@ -0,0 +1,182 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Formatter is the behavior of a type that converts values to fixed-length text. It is the reverse of a Converter.
+type Formatter interface {
+	FromNumber(num int) ([]byte, error)
+	FromCurrency(value decimal.Decimal) ([]byte, error)
+	FromFlags(flags product.Flags) ([]byte, error)
+}
+
+// Encoder writes product records as fixed-width flat-file lines, in the format read by a Parser.
+type Encoder struct {
+	dst    io.Writer
+	format Formatter
+	layout Layout
+	fields fields
+}
+
+// NewEncoder creates an encoder that writes records with the given layout.
+func NewEncoder(output io.Writer, f Formatter, layout Layout) (*Encoder, error) {
+	if output == nil || f == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+	if err := layout.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Encoder{
+		dst:    output,
+		format: f,
+		layout: layout,
+		fields: layout.fields(),
+	}, nil
+}
+
+// Encode writes a record as a single UTF-8 line, ending in LF.
+func (e *Encoder) Encode(r *product.Record) error {
+	line, err := e.EncodeRecord(r)
+	if err != nil {
+		return err
+	}
+
+	line = append(line, '\n')
+	_, err = e.dst.Write(line)
+	return errors.WithStack(err)
+}
+
+// EncodeRecord returns the text of a record, without a line ending.
+func (e *Encoder) EncodeRecord(r *product.Record) ([]byte, error) {
+	if r == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+
+	f := &e.fields
+	line := bytes.Repeat([]byte{' '}, e.layout.Length)
+
+	if err := e.number(line, f.id, r.ID); err != nil {
+		return nil, err
+	}
+	if err := e.text(line, f.description, r.Description); err != nil {
+		return nil, err
+	}
+
+	// A split price is written as a zero singular price, followed by the split price and quantity.
+	if r.IsSplitPrice() && f.splitPrice.Present() {
+		if err := e.currency(line, f.price, decimal.Zero); err != nil {
+			return nil, err
+		}
+		if err := e.currency(line, f.splitPrice, r.SplitPrice); err != nil {
+			return nil, err
+		}
+		if err := e.number(line, f.forX, r.SplitQuantity); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := e.currency(line, f.price, r.Price); err != nil {
+			return nil, err
+		}
+		if err := e.currency(line, f.splitPrice, decimal.Zero); err != nil {
+			return nil, err
+		}
+		if err := e.number(line, f.forX, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.IsSplitPromoPrice() && f.splitPromoPrice.Present() {
+		if err := e.currency(line, f.promoPrice, decimal.Zero); err != nil {
+			return nil, err
+		}
+		if err := e.currency(line, f.splitPromoPrice, r.PromoSplitPrice); err != nil {
+			return nil, err
+		}
+		if err := e.number(line, f.promoForX, r.PromoSplitQuantity); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := e.currency(line, f.promoPrice, r.PromoPrice); err != nil {
+			return nil, err
+		}
+		if err := e.currency(line, f.splitPromoPrice, decimal.Zero); err != nil {
+			return nil, err
+		}
+		if err := e.number(line, f.promoForX, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.flags(line, f.flags, r); err != nil {
+		return nil, err
+	}
+	if err := e.text(line, f.size, r.Size); err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
+
+func (e *Encoder) number(line []byte, f Field, num int) error {
+	if !f.Present() {
+		return nil
+	}
+	text, err := e.format.FromNumber(num)
+	return place(line, f, text, err)
+}
+
+func (e *Encoder) currency(line []byte, f Field, value decimal.Decimal) error {
+	if !f.Present() {
+		return nil
+	}
+	text, err := e.format.FromCurrency(value)
+	return place(line, f, text, err)
+}
+
+// flags writes the record's flags, including those implied by its unit of measure and tax rate.
+func (e *Encoder) flags(line []byte, f Field, r *product.Record) error {
+	flags := r.Flags
+	if r.Unit == product.UnitPound {
+		flags |= product.FlagPerWeight
+	}
+	if !r.TaxRate.IsZero() {
+		flags |= product.FlagTaxable
+	}
+
+	text, err := e.format.FromFlags(flags)
+	return place(line, f, text, err)
+}
+
+func (e *Encoder) text(line []byte, f Field, value string) error {
+	if !f.Present() {
+		return nil
+	}
+	if len(value) > f.Length {
+		return errors.Wrapf(product.ErrBadFieldLength, "Error encoding %s", f.Name)
+	}
+
+	padding := bytes.Repeat([]byte{' '}, f.Length-len(value))
+	if f.Align == AlignRight {
+		return place(line, f, append(padding, value...), nil)
+	}
+	return place(line, f, append([]byte(value), padding...), nil)
+}
+
+// place copies the text of a field into its position in the line.
+func place(line []byte, f Field, text []byte, err error) error {
+	if err != nil {
+		return errors.Wrapf(err, "Error encoding %s", f.Name)
+	}
+	if len(text) != f.Length {
+		return errors.Wrapf(product.ErrBadFieldLength, "Error encoding %s", f.Name)
+	}
+	copy(line[f.Start:], text)
+	return nil
+}
@@ -0,0 +1,148 @@
+package parser
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type encoderTestSuite struct {
+	suite.Suite
+	converter *product.Converter
+}
+
+func Test_Encoder(t *testing.T) {
+	s := new(encoderTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *encoderTestSuite) SetupSuite() {
+	s.converter, _ = product.NewConverter(NumberFieldLength, CurrencyFieldLength, FlagsFieldLength)
+}
+
+func (s *encoderTestSuite) Test_NewEncoder_NoOutput_ReturnsError() {
+	_, err := NewEncoder(nil, s.converter, DefaultLayout())
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *encoderTestSuite) Test_NewEncoder_InvalidLayout_ReturnsError() {
+	_, err := NewEncoder(&bytes.Buffer{}, s.converter, Layout{})
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadLayout, errors.Cause(err))
+}
+
+func (s *encoderTestSuite) Test_Encode_SampleFile_RoundTrips() {
+	t := s.T()
+
+	raw, err := ioutil.ReadFile("../cmd/ingester/input-sample.txt")
+	require.NoError(t, err)
+
+	// The sample file has CRLF line endings, and the encoder ends lines with LF.
+	require.Contains(t, string(raw), "\r\n")
+	expected := bytes.Replace(raw, []byte("\r\n"), []byte("\n"), -1)
+
+	p, _ := New(bytes.NewReader(nil), s.converter)
+	var output bytes.Buffer
+	e, err := NewEncoder(&output, s.converter, DefaultLayout())
+	require.NoError(t, err)
+
+	lines := bytes.SplitAfter(expected, []byte("\n"))
+	for i, line := range lines[:len(lines)-1] {
+		r, err := p.ParseRecord(i+1, bytes.TrimSuffix(line, []byte("\n")))
+		require.NoError(t, err)
+		require.NoError(t, e.Encode(r))
+	}
+	require.Empty(t, lines[len(lines)-1])
+
+	require.Equal(t, string(expected), output.String())
+}
+
+func (s *encoderTestSuite) Test_Encode_SplitPromoPrice_RoundTrips() {
+	t := s.T()
+
+	row := []byte("14963801 Generic Soda 12-pack                                        00000549 00000000 00000000 00001000 00000000 00000002 NNNNYNNNN   12x12oz")
+	p, _ := New(bytes.NewReader(nil), s.converter)
+	r, err := p.ParseRecord(1, row)
+	require.NoError(t, err)
+
+	e, _ := NewEncoder(&bytes.Buffer{}, s.converter, DefaultLayout())
+	line, err := e.EncodeRecord(r)
+	require.NoError(t, err)
+	require.Equal(t, string(row), string(line))
+}
+
+func (s *encoderTestSuite) Test_EncodeRecord_NoFlags_WritesSingularPricesAndDerivedFlags() {
+	t := s.T()
+
+	r := &product.Record{
+		ID:          50133333,
+		Description: "Fuji Apples (Organic)",
+		Price:       decimal.New(349, -2),
+		PromoPrice:  decimal.Zero,
+		Unit:        product.UnitPound,
+		Size:        "lb",
+		TaxRate:     decimal.New(7775, -5),
+	}
+
+	e, _ := NewEncoder(&bytes.Buffer{}, s.converter, DefaultLayout())
+	line, err := e.EncodeRecord(r)
+	require.NoError(t, err)
+	require.Equal(t,
+		"50133333 Fuji Apples (Organic)                                       00000349 00000000 00000000 00000000 00000000 00000000 NNYNYNNNN        lb",
+		string(line))
+}
+
+func (s *encoderTestSuite) Test_EncodeRecord_NamedFlags_WritesPositions() {
+	t := s.T()
+
+	r := &product.Record{
+		ID:          40123401,
+		Description: "Marlboro Cigarettes",
+		Price:       decimal.New(10, 0),
+		PromoPrice:  decimal.New(549, -2),
+		Unit:        product.UnitEach,
+		Flags:       product.FlagAgeRestricted | product.FlagDiscontinued,
+	}
+
+	e, _ := NewEncoder(&bytes.Buffer{}, s.converter, DefaultLayout())
+	line, err := e.EncodeRecord(r)
+	require.NoError(t, err)
+	require.Equal(t,
+		"40123401 Marlboro Cigarettes                                         00001000 00000549 00000000 00000000 00000000 00000000 YNNNNNNNY          ",
+		string(line))
+}
+
+func (s *encoderTestSuite) Test_EncodeRecord_DescriptionTooLong_ReturnsError() {
+	t := s.T()
+
+	r := &product.Record{
+		ID:          80000001,
+		Description: string(bytes.Repeat([]byte{'x'}, 60)),
+	}
+
+	e, _ := NewEncoder(&bytes.Buffer{}, s.converter, DefaultLayout())
+	_, err := e.EncodeRecord(r)
+	require.Error(t, err)
+	require.Equal(t, product.ErrBadFieldLength, errors.Cause(err))
+}
+
+func (s *encoderTestSuite) Test_EncodeRecord_FractionalCentPrice_ReturnsError() {
+	t := s.T()
+
+	r := &product.Record{
+		ID:    14963801,
+		Price: decimal.New(65, -3),
+	}
+
+	e, _ := NewEncoder(&bytes.Buffer{}, s.converter, DefaultLayout())
+	_, err := e.EncodeRecord(r)
+	require.Error(t, err)
+	require.Equal(t, product.ErrBadFormat, errors.Cause(err))
+}
@@ -1,7 +1,39 @@
 package parser
 
 import (
+	stderrors "errors"
 	"fmt"
+
+	"github.com/jessejohnston/ProductIngester/charset"
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/jessejohnston/ProductIngester/tax"
+	"github.com/pkg/errors"
+)
+
+// Code is a stable identifier for a kind of parser failure, suitable for grouping errors.
+type Code string
+
+const (
+	// CodeUnknown identifies a failure of an unrecognized kind.
+	CodeUnknown Code = "unknown"
+
+	// CodeBadLength identifies a record that isn't the length of the layout.
+	CodeBadLength Code = "bad_length"
+
+	// CodeBadFieldLength identifies a field that isn't the length expected by the converter.
+	CodeBadFieldLength Code = "bad_field_length"
+
+	// CodeBadFormat identifies a field with unexpected content.
+	CodeBadFormat Code = "bad_format"
+
+	// CodeZeroForX identifies a split price for a quantity of zero.
+	CodeZeroForX Code = "zero_for_x"
+
+	// CodeNoTaxRate identifies a taxable product that the tax policy has no rate for.
+	CodeNoTaxRate Code = "no_tax_rate"
+
+	// CodeBadEncoding identifies a record with bytes that aren't valid in the parser's charset.
+	CodeBadEncoding Code = "bad_encoding"
 )
 
 // Error is a product parser error
@@ -9,8 +41,10 @@ type Error struct {
 	line  int
 	col   int
 	field []byte
+	name  string
 	msg   string
 	err   error
+	code  Code
 }
 
 // NewParserError creates a new product parser error
@@ -21,9 +55,100 @@ func NewParserError(line, col int, field []byte, msg string, err error) Error {
 		field: field,
 		msg:   msg,
 		err:   err,
+		code:  codeFor(err),
 	}
 }
 
+// fieldError creates a parser error for the text of a layout field.
+func fieldError(line int, f Field, text []byte, msg string, err error) Error {
+	e := NewParserError(line, f.Start, text, msg, err)
+	e.name = f.Name
+	return e
+}
+
+// encodingError creates a parser error for a record with an invalid byte at offset, in the field containing it.
+func encodingError(line int, layout Layout, text []byte, offset int) Error {
+	for _, f := range layout.Fields {
+		if offset >= f.Start && offset < f.End() {
+			return fieldError(line, f, slice(text, f), "Error decoding text", charset.ErrBadEncoding)
+		}
+	}
+	return NewParserError(line, offset, text[offset:offset+1], "Error decoding text", charset.ErrBadEncoding)
+}
+
+// recordLengthError creates a parser error for a record of the wrong length.
+func recordLengthError(line, length, expected int) Error {
+	e := NewParserError(line, 0, nil, fmt.Sprintf("Unexpected record length %d, expected %d", length, expected), ErrBadParameter)
+	e.code = CodeBadLength
+	return e
+}
+
 func (e Error) Error() string {
 	return fmt.Sprintf("(%d, %d): \"%s\" %s: %v", e.line, e.col, string(e.field), e.msg, e.err)
 }
+
+// Line returns the 1-based line number of the record that failed to parse.
+func (e Error) Line() int {
+	return e.line
+}
+
+// Column returns the zero-based offset of the field that failed to parse.
+func (e Error) Column() int {
+	return e.col
+}
+
+// Field returns the text of the field that failed to parse.
+func (e Error) Field() []byte {
+	return e.field
+}
+
+// FieldName returns the layout name of the field that failed to parse, or "" if the failure isn't specific to a field.
+func (e Error) FieldName() string {
+	return e.name
+}
+
+// Message returns a description of the failure, without its location or cause.
+func (e Error) Message() string {
+	return e.msg
+}
+
+// Code returns the kind of failure.
+func (e Error) Code() Code {
+	return e.code
+}
+
+// Unwrap returns the underlying cause of the failure, for use with errors.Is and errors.As.
+func (e Error) Unwrap() error {
+	return errors.Cause(e.err)
+}
+
+// Cause returns the underlying cause of the failure, for use with errors.Cause.
+func (e Error) Cause() error {
+	return errors.Cause(e.err)
+}
+
+// CodeOf returns the kind of a parser failure, or CodeUnknown if err isn't a parser Error.
+func CodeOf(err error) Code {
+	var e Error
+	if stderrors.As(err, &e) {
+		return e.code
+	}
+	return CodeUnknown
+}
+
+// codeFor returns the kind of failure caused by err.
+func codeFor(err error) Code {
+	switch errors.Cause(err) {
+	case product.ErrBadFieldLength:
+		return CodeBadFieldLength
+	case product.ErrBadFormat:
+		return CodeBadFormat
+	case ErrZeroForX:
+		return CodeZeroForX
+	case tax.ErrNoRate:
+		return CodeNoTaxRate
+	case charset.ErrBadEncoding:
+		return CodeBadEncoding
+	}
+	return CodeUnknown
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type errorTestSuite struct {
+	suite.Suite
+	parser *Parser
+}
+
+func Test_Error(t *testing.T) {
+	s := new(errorTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *errorTestSuite) SetupSuite() {
+	converter, _ := product.NewConverter(NumberFieldLength, CurrencyFieldLength, FlagsFieldLength)
+	s.parser, _ = New(strings.NewReader("the file"), converter)
+}
+
+func (s *errorTestSuite) Test_NewParserError_HasAccessors() {
+	t := s.T()
+
+	err := NewParserError(3, 69, []byte("0000ABCD"), "Error parsing singular price", errors.WithStack(product.ErrBadFormat))
+	require.Equal(t, 3, err.Line())
+	require.Equal(t, 69, err.Column())
+	require.Equal(t, []byte("0000ABCD"), err.Field())
+	require.Empty(t, err.FieldName())
+	require.Equal(t, "Error parsing singular price", err.Message())
+	require.Equal(t, CodeBadFormat, err.Code())
+}
+
+func (s *errorTestSuite) Test_ParseRecord_BadField_ErrorIsBadFormat() {
+	t := s.T()
+
+	row := []byte("8000000X Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz")
+	_, err := s.parser.ParseRecord(7, row)
+	require.Error(t, err)
+
+	require.True(t, stderrors.Is(err, product.ErrBadFormat))
+	require.Equal(t, product.ErrBadFormat, errors.Cause(err))
+
+	var e Error
+	require.True(t, stderrors.As(err, &e))
+	require.Equal(t, 7, e.Line())
+	require.Equal(t, 0, e.Column())
+	require.Equal(t, FieldID, e.FieldName())
+	require.Equal(t, []byte("8000000X"), e.Field())
+	require.Equal(t, CodeBadFormat, CodeOf(err))
+}
+
+func (s *errorTestSuite) Test_ParseRecord_ZeroForX_ErrorIsZeroForX() {
+	t := s.T()
+
+	row := []byte("14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000000 00000000 NNNNYNNNN   12x12oz")
+	_, err := s.parser.ParseRecord(1, row)
+	require.Error(t, err)
+
+	require.True(t, stderrors.Is(err, ErrZeroForX))
+	require.Equal(t, CodeZeroForX, CodeOf(err))
+	require.Equal(t, FieldForX, err.(Error).FieldName())
+	require.Equal(t, 105, err.(Error).Column())
+}
+
+func (s *errorTestSuite) Test_ParseRecord_BadLength_ErrorIsBadLength() {
+	t := s.T()
+
+	_, err := s.parser.ParseRecord(2, []byte("40123401 Marlboro Cigare"))
+	require.Error(t, err)
+
+	require.True(t, stderrors.Is(err, ErrBadParameter))
+	require.Equal(t, CodeBadLength, CodeOf(err))
+	require.Equal(t, 2, err.(Error).Line())
+}
+
+func (s *errorTestSuite) Test_CodeOf_OtherError_IsUnknown() {
+	require.Equal(s.T(), CodeUnknown, CodeOf(errors.New("something else")))
+}
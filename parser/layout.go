@@ -0,0 +1,304 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// FieldKind identifies the conversion applied to a record field.
+type FieldKind string
+
+const (
+	// KindNumber is an integer field.
+	KindNumber FieldKind = "number"
+
+	// KindCurrency is a currency field, expressed in cents.
+	KindCurrency FieldKind = "currency"
+
+	// KindString is a free text field.
+	KindString FieldKind = "string"
+
+	// KindFlags is a Y/N flags field.
+	KindFlags FieldKind = "flags"
+)
+
+// Alignments of string field text, used when encoding records.
+const (
+	AlignLeft  = "left"
+	AlignRight = "right"
+)
+
+// Names of the record fields understood by the parser.
+const (
+	FieldID              = "id"
+	FieldDescription     = "description"
+	FieldPrice           = "price"
+	FieldPromoPrice      = "promo_price"
+	FieldSplitPrice      = "split_price"
+	FieldSplitPromoPrice = "split_promo_price"
+	FieldForX            = "for_x"
+	FieldPromoForX       = "promo_for_x"
+	FieldFlags           = "flags"
+	FieldSize            = "size"
+)
+
+// Layout formats accepted by ReadLayout.
+const (
+	LayoutJSON = "json"
+	LayoutYAML = "yaml"
+)
+
+var (
+	// ErrBadLayout is the error returned when a record layout is invalid.
+	ErrBadLayout = errors.New("Invalid record layout")
+
+	// fieldKinds is the kind each known field must have.
+	fieldKinds = map[string]FieldKind{
+		FieldID:              KindNumber,
+		FieldDescription:     KindString,
+		FieldPrice:           KindCurrency,
+		FieldPromoPrice:      KindCurrency,
+		FieldSplitPrice:      KindCurrency,
+		FieldSplitPromoPrice: KindCurrency,
+		FieldForX:            KindNumber,
+		FieldPromoForX:       KindNumber,
+		FieldFlags:           KindFlags,
+		FieldSize:            KindString,
+	}
+
+	// requiredFields must be present in every layout. All other known fields are optional.
+	requiredFields = []string{FieldID, FieldDescription, FieldPrice, FieldFlags}
+
+	// pairedFields are split price fields that are only meaningful with their for X quantity.
+	pairedFields = map[string]string{
+		FieldSplitPrice:      FieldForX,
+		FieldSplitPromoPrice: FieldPromoForX,
+	}
+)
+
+// Field describes the position and kind of a single fixed-width field.
+type Field struct {
+	Name   string    `json:"name" yaml:"name"`
+	Start  int       `json:"start" yaml:"start"`
+	Length int       `json:"length" yaml:"length"`
+	Kind   FieldKind `json:"kind" yaml:"kind"`
+
+	// Align is the alignment of a string field's text within the field, AlignLeft (the default) or AlignRight.
+	Align string `json:"align,omitempty" yaml:"align,omitempty"`
+}
+
+// End returns the offset just past the last byte of the field.
+func (f Field) End() int {
+	return f.Start + f.Length
+}
+
+// Present returns true if the field is part of the layout.
+func (f Field) Present() bool {
+	return f.Length > 0
+}
+
+// Layout describes the fixed-width fields of a flat-file record.
+type Layout struct {
+	Length int     `json:"length" yaml:"length"`
+	Fields []Field `json:"fields" yaml:"fields"`
+}
+
+// DefaultLayout returns the layout of the standard 142 byte store catalog record.
+func DefaultLayout() Layout {
+	return Layout{
+		Length: RecordLength,
+		Fields: []Field{
+			{Name: FieldID, Start: 0, Length: NumberFieldLength, Kind: KindNumber},
+			{Name: FieldDescription, Start: 9, Length: 59, Kind: KindString},
+			{Name: FieldPrice, Start: 69, Length: CurrencyFieldLength, Kind: KindCurrency},
+			{Name: FieldPromoPrice, Start: 78, Length: CurrencyFieldLength, Kind: KindCurrency},
+			{Name: FieldSplitPrice, Start: 87, Length: CurrencyFieldLength, Kind: KindCurrency},
+			{Name: FieldSplitPromoPrice, Start: 96, Length: CurrencyFieldLength, Kind: KindCurrency},
+			{Name: FieldForX, Start: 105, Length: NumberFieldLength, Kind: KindNumber},
+			{Name: FieldPromoForX, Start: 114, Length: NumberFieldLength, Kind: KindNumber},
+			{Name: FieldFlags, Start: 123, Length: FlagsFieldLength, Kind: KindFlags},
+			{Name: FieldSize, Start: 133, Length: 9, Kind: KindString, Align: AlignRight},
+		},
+	}
+}
+
+// LoadLayout reads a layout from a JSON or YAML file, chosen by the file extension.
+func LoadLayout(filename string) (Layout, error) {
+	var format string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		format = LayoutJSON
+	case ".yaml", ".yml":
+		format = LayoutYAML
+	default:
+		return Layout{}, errors.Wrapf(ErrBadLayout, "unknown layout file type %s", filename)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Layout{}, errors.WithStack(err)
+	}
+
+	return ReadLayout(bytes.NewReader(data), format)
+}
+
+// ReadLayout decodes and validates a layout in the given format (LayoutJSON or LayoutYAML).
+func ReadLayout(r io.Reader, format string) (Layout, error) {
+	if r == nil {
+		return Layout{}, errors.WithStack(ErrBadParameter)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Layout{}, errors.WithStack(err)
+	}
+
+	var l Layout
+	switch format {
+	case LayoutJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&l)
+	case LayoutYAML:
+		err = yaml.UnmarshalStrict(data, &l)
+	default:
+		return Layout{}, errors.WithStack(ErrBadParameter)
+	}
+	if err != nil {
+		return Layout{}, errors.Wrap(ErrBadLayout, err.Error())
+	}
+
+	// A layout without an explicit length ends with its last field.
+	if l.Length == 0 {
+		for _, f := range l.Fields {
+			if f.End() > l.Length {
+				l.Length = f.End()
+			}
+		}
+	}
+
+	if err := l.Validate(); err != nil {
+		return Layout{}, err
+	}
+
+	return l, nil
+}
+
+// Validate checks that the layout fields are known, fit in the record, and can be converted.
+func (l Layout) Validate() error {
+	if l.Length < 1 {
+		return errors.Wrap(ErrBadLayout, "record length must be positive")
+	}
+
+	seen := make(map[string]bool)
+	kindLength := make(map[FieldKind]int)
+
+	for _, f := range l.Fields {
+		kind, ok := fieldKinds[f.Name]
+		if !ok {
+			return errors.Wrapf(ErrBadLayout, "unknown field %q", f.Name)
+		}
+		if seen[f.Name] {
+			return errors.Wrapf(ErrBadLayout, "duplicate field %q", f.Name)
+		}
+		seen[f.Name] = true
+
+		if f.Kind != kind {
+			return errors.Wrapf(ErrBadLayout, "field %q must be of kind %s", f.Name, kind)
+		}
+		if f.Align != "" && f.Align != AlignLeft && f.Align != AlignRight {
+			return errors.Wrapf(ErrBadLayout, "field %q has unknown alignment %q", f.Name, f.Align)
+		}
+		if f.Start < 0 || f.Length < 1 || f.End() > l.Length {
+			return errors.Wrapf(ErrBadLayout, "field %q does not fit in a %d byte record", f.Name, l.Length)
+		}
+
+		// The converter uses a single length for each non-string kind.
+		if f.Kind != KindString {
+			if n, ok := kindLength[f.Kind]; ok && n != f.Length {
+				return errors.Wrapf(ErrBadLayout, "all %s fields must have the same length", f.Kind)
+			}
+			kindLength[f.Kind] = f.Length
+		}
+	}
+
+	for _, name := range requiredFields {
+		if !seen[name] {
+			return errors.Wrapf(ErrBadLayout, "missing required field %q", name)
+		}
+	}
+
+	for price, quantity := range pairedFields {
+		if seen[price] != seen[quantity] {
+			return errors.Wrapf(ErrBadLayout, "fields %q and %q must be used together", price, quantity)
+		}
+	}
+
+	return nil
+}
+
+// Field returns the named field. The returned field is not Present if the layout doesn't include it.
+func (l Layout) Field(name string) Field {
+	for _, f := range l.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return Field{Name: name, Kind: fieldKinds[name]}
+}
+
+// FieldLength returns the length of the fields of the given kind, or the default length if the layout has none.
+func (l Layout) FieldLength(kind FieldKind) int {
+	for _, f := range l.Fields {
+		if f.Kind == kind {
+			return f.Length
+		}
+	}
+
+	switch kind {
+	case KindNumber:
+		return NumberFieldLength
+	case KindCurrency:
+		return CurrencyFieldLength
+	case KindFlags:
+		return FlagsFieldLength
+	}
+	return 0
+}
+
+// fields holds the layout fields used by the parser and encoder, resolved once.
+type fields struct {
+	id              Field
+	description     Field
+	price           Field
+	promoPrice      Field
+	splitPrice      Field
+	splitPromoPrice Field
+	forX            Field
+	promoForX       Field
+	flags           Field
+	size            Field
+}
+
+// fields resolves the fields used by the parser and encoder.
+func (l Layout) fields() fields {
+	return fields{
+		id:              l.Field(FieldID),
+		description:     l.Field(FieldDescription),
+		price:           l.Field(FieldPrice),
+		promoPrice:      l.Field(FieldPromoPrice),
+		splitPrice:      l.Field(FieldSplitPrice),
+		splitPromoPrice: l.Field(FieldSplitPromoPrice),
+		forX:            l.Field(FieldForX),
+		promoForX:       l.Field(FieldPromoForX),
+		flags:           l.Field(FieldFlags),
+		size:            l.Field(FieldSize),
+	}
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type layoutTestSuite struct {
+	suite.Suite
+}
+
+func Test_Layout(t *testing.T) {
+	s := new(layoutTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *layoutTestSuite) Test_DefaultLayout_IsValid() {
+	l := DefaultLayout()
+	require.NoError(s.T(), l.Validate())
+	require.Equal(s.T(), RecordLength, l.Length)
+}
+
+func (s *layoutTestSuite) Test_LoadLayout_SampleFile_MatchesDefault() {
+	l, err := LoadLayout("../cmd/ingester/layout-sample.yaml")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), DefaultLayout(), l)
+}
+
+func (s *layoutTestSuite) Test_LoadLayout_UnknownExtension_ReturnsError() {
+	_, err := LoadLayout("layout.txt")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadLayout, errors.Cause(err))
+}
+
+func (s *layoutTestSuite) Test_ReadLayout_JSON_ReturnsLayout() {
+	l, err := ReadLayout(strings.NewReader(`{
+		"fields": [
+			{"name": "id", "start": 0, "length": 6, "kind": "number"},
+			{"name": "description", "start": 6, "length": 20, "kind": "string"},
+			{"name": "price", "start": 26, "length": 7, "kind": "currency"},
+			{"name": "flags", "start": 33, "length": 9, "kind": "flags"}
+		]
+	}`), LayoutJSON)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 42, l.Length)
+	require.Equal(s.T(), 6, l.FieldLength(KindNumber))
+	require.Equal(s.T(), 7, l.FieldLength(KindCurrency))
+	require.False(s.T(), l.Field(FieldSize).Present())
+}
+
+func (s *layoutTestSuite) Test_ReadLayout_UnknownFormat_ReturnsError() {
+	_, err := ReadLayout(strings.NewReader("{}"), "xml")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *layoutTestSuite) Test_ReadLayout_UnknownField_ReturnsError() {
+	_, err := ReadLayout(strings.NewReader(`
+fields:
+  - {name: id, start: 0, length: 8, kind: number}
+  - {name: color, start: 8, length: 8, kind: string}
+`), LayoutYAML)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadLayout, errors.Cause(err))
+}
+
+func (s *layoutTestSuite) Test_Validate_MissingRequiredField_ReturnsError() {
+	l := DefaultLayout()
+	l.Fields = l.Fields[1:]
+	require.Equal(s.T(), ErrBadLayout, errors.Cause(l.Validate()))
+}
+
+func (s *layoutTestSuite) Test_Validate_WrongKind_ReturnsError() {
+	l := DefaultLayout()
+	l.Fields[0].Kind = KindString
+	require.Equal(s.T(), ErrBadLayout, errors.Cause(l.Validate()))
+}
+
+func (s *layoutTestSuite) Test_Validate_FieldPastEndOfRecord_ReturnsError() {
+	l := DefaultLayout()
+	l.Length = 140
+	require.Equal(s.T(), ErrBadLayout, errors.Cause(l.Validate()))
+}
+
+func (s *layoutTestSuite) Test_Validate_MismatchedKindLengths_ReturnsError() {
+	l := DefaultLayout()
+	l.Fields[2].Length = 7
+	require.Equal(s.T(), ErrBadLayout, errors.Cause(l.Validate()))
+}
+
+func (s *layoutTestSuite) Test_Validate_SplitPriceWithoutForX_ReturnsError() {
+	l := DefaultLayout()
+	var fields []Field
+	for _, f := range l.Fields {
+		if f.Name != FieldForX {
+			fields = append(fields, f)
+		}
+	}
+	l.Fields = fields
+	require.Equal(s.T(), ErrBadLayout, errors.Cause(l.Validate()))
+}
+
+func (s *layoutTestSuite) Test_NewParser_InvalidLayout_ReturnsError() {
+	l := DefaultLayout()
+	l.Length = 0
+
+	convert, _ := product.NewConverter(NumberFieldLength, CurrencyFieldLength, FlagsFieldLength)
+	_, err := New(strings.NewReader("the file"), convert, WithLayout(l))
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadLayout, errors.Cause(err))
+}
+
+func (s *layoutTestSuite) Test_ParseRecord_CustomLayout_ReadsShiftedFields() {
+	t := s.T()
+
+	l, err := ReadLayout(strings.NewReader(`
+length: 40
+fields:
+  - {name: id,          start: 0,  length: 8,  kind: number}
+  - {name: flags,       start: 8,  length: 9,  kind: flags}
+  - {name: price,       start: 17, length: 8,  kind: currency}
+  - {name: description, start: 25, length: 15, kind: string}
+`), LayoutYAML)
+	require.NoError(t, err)
+
+	convert, _ := product.NewConverter(l.FieldLength(KindNumber), l.FieldLength(KindCurrency), l.FieldLength(KindFlags))
+	p, err := New(strings.NewReader("the file"), convert, WithLayout(l))
+	require.NoError(t, err)
+
+	r, err := p.ParseRecord(1, []byte("80000001NNNNYNNNN00000567Kimchi rice    "))
+	require.NoError(t, err)
+
+	require.Equal(t, 80000001, r.ID)
+	require.Equal(t, "Kimchi rice", r.Description)
+	require.True(t, r.Price.Equals(decimal.New(567, -2)))
+	require.True(t, r.PromoPrice.Equals(decimal.Zero))
+	require.Equal(t, product.UnitEach, r.Unit)
+	require.Empty(t, r.Size)
+}
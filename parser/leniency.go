@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Normalization is a change made to a line so that it can be parsed.
+type Normalization string
+
+const (
+	// NormalizationStripCR removes the carriage return of a CRLF line ending. It is applied to every line, whatever
+	// the leniency.
+	NormalizationStripCR Normalization = "strip_cr"
+
+	// NormalizationPadShort pads a short line with spaces, when the fields it is missing are all string fields,
+	// which may be blank. Such lines are written by tools that trim trailing spaces.
+	NormalizationPadShort Normalization = "pad_short"
+
+	// NormalizationTrimLong removes whitespace past the end of a long line.
+	NormalizationTrimLong Normalization = "trim_long"
+)
+
+// Leniency is the policy for normalizing lines that aren't the layout's length. A line that is still the wrong
+// length after normalization fails to parse.
+type Leniency struct {
+	PadShort bool
+	TrimLong bool
+}
+
+var (
+	// DefaultLeniency applies no normalizations.
+	DefaultLeniency = Leniency{}
+
+	// Lenient applies every normalization.
+	Lenient = Leniency{PadShort: true, TrimLong: true}
+)
+
+// ParseLeniency returns the leniency that applies the named normalizations, such as "pad_short" and "trim_long".
+// "strip_cr" is accepted, but is always applied.
+func ParseLeniency(names ...string) (Leniency, error) {
+	var l Leniency
+	for _, name := range names {
+		switch Normalization(strings.TrimSpace(name)) {
+		case NormalizationPadShort:
+			l.PadShort = true
+		case NormalizationTrimLong:
+			l.TrimLong = true
+		case NormalizationStripCR, "":
+		default:
+			return Leniency{}, errors.Wrapf(ErrBadParameter, "unknown normalization %q", name)
+		}
+	}
+	return l, nil
+}
+
+// WithLeniency configures the normalizations the parser applies to lines that aren't the layout's length,
+// instead of DefaultLeniency.
+func WithLeniency(l Leniency) Option {
+	return func(p *Parser) error {
+		p.leniency = l
+		return nil
+	}
+}
+
+// normalize applies the parser's leniency to a line, returning the line and the normalizations applied to it,
+// including the removal of a carriage return from the line's ending, which splitLine has already done.
+func (p *Parser) normalize(text, ending []byte) ([]byte, []Normalization) {
+	var applied []Normalization
+	if len(ending) > 0 && ending[0] == '\r' {
+		applied = append(applied, NormalizationStripCR)
+	}
+
+	length := p.layout.Length
+	switch {
+	case len(text) > length && p.leniency.TrimLong && p.blank(text[length:]):
+		text = text[:length]
+		applied = append(applied, NormalizationTrimLong)
+
+	case len(text) > 0 && len(text) < length && p.leniency.PadShort && p.layout.stringsFrom(len(text)):
+		padded := make([]byte, length)
+		copy(padded, text)
+		for i := len(text); i < length; i++ {
+			padded[i] = p.charset.Space()
+		}
+		text = padded
+		applied = append(applied, NormalizationPadShort)
+	}
+
+	return text, applied
+}
+
+// stringsFrom returns true if every field that ends past offset is a string field, which may be blank.
+func (l Layout) stringsFrom(offset int) bool {
+	for _, f := range l.Fields {
+		if f.End() > offset && f.Kind != KindString {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type leniencyTestSuite struct {
+	suite.Suite
+	converter Converter
+}
+
+func Test_Leniency(t *testing.T) {
+	s := new(leniencyTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *leniencyTestSuite) SetupSuite() {
+	s.converter, _ = product.NewConverter(NumberFieldLength, CurrencyFieldLength, FlagsFieldLength)
+}
+
+// marlboroRow is a record without a size, with its trailing spaces trimmed.
+const marlboroRow = "40123401 Marlboro Cigarettes                                         00001000 00000549 00000000 00000000 00000000 00000000 YNNNNNNNN"
+
+func (s *leniencyTestSuite) parser(opts ...Option) *Parser {
+	p, err := New(strings.NewReader("the file"), s.converter, opts...)
+	require.NoError(s.T(), err)
+	return p
+}
+
+func (s *leniencyTestSuite) Test_ParseLeniency_Names_SetsNormalizations() {
+	l, err := ParseLeniency("trim_long", " pad_short", "", "strip_cr")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), Lenient, l)
+
+	l, err = ParseLeniency("")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), Leniency{}, l)
+}
+
+func (s *leniencyTestSuite) Test_ParseLeniency_UnknownName_ReturnsError() {
+	_, err := ParseLeniency("strip_tabs")
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *leniencyTestSuite) Test_Results_CRLF_NoLeniency_ParsesRecords() {
+	t := s.T()
+
+	p, _ := New(strings.NewReader(kimchiRow+"\r\n"+kimchiRow+"\r\n"), s.converter, WithLeniency(Leniency{}))
+
+	var results []Result
+	for r := range p.Results(context.Background()) {
+		require.NoError(t, r.Err)
+		results = append(results, r)
+	}
+	require.NoError(t, p.Err())
+
+	require.Len(t, results, 2)
+	require.Equal(t, "18oz", results[1].Record.Size)
+	require.Equal(t, []Normalization{NormalizationStripCR}, results[1].Normalized)
+	require.Equal(t, map[Normalization]int{NormalizationStripCR: 2}, p.Stats().Normalized)
+}
+
+func (s *leniencyTestSuite) Test_ParseRecord_CRLF_StripsLineEnding() {
+	r, err := s.parser().ParseRecord(1, []byte(kimchiRow+"\r\n"))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "18oz", r.Size)
+}
+
+func (s *leniencyTestSuite) Test_ParseRecord_TrimmedBlankSize_PadShort_PadsRecord() {
+	_, err := s.parser().ParseRecord(1, []byte(marlboroRow))
+	require.Equal(s.T(), CodeBadLength, CodeOf(err))
+
+	r, err := s.parser(WithLeniency(Lenient)).ParseRecord(1, []byte(marlboroRow))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 40123401, r.ID)
+	require.Equal(s.T(), "", r.Size)
+}
+
+func (s *leniencyTestSuite) Test_ParseRecord_ShortInNumberField_PadShort_ReturnsError() {
+	_, err := s.parser(WithLeniency(Lenient)).ParseRecord(1, []byte(kimchiRow[:100]))
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *leniencyTestSuite) Test_ParseRecord_TrailingSpaces_TrimLong_TrimsRecord() {
+	_, err := s.parser().ParseRecord(1, []byte(kimchiRow+"   "))
+	require.Equal(s.T(), CodeBadLength, CodeOf(err))
+
+	r, err := s.parser(WithLeniency(Lenient)).ParseRecord(1, []byte(kimchiRow+" \t "))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "18oz", r.Size)
+}
+
+func (s *leniencyTestSuite) Test_ParseRecord_TrailingText_TrimLong_ReturnsError() {
+	_, err := s.parser(WithLeniency(Lenient)).ParseRecord(1, []byte(kimchiRow+"zz"))
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *leniencyTestSuite) Test_Results_ReportsNormalizations() {
+	t := s.T()
+
+	reader := strings.NewReader(
+		kimchiRow + "\r\n" +
+			marlboroRow + "\r\n" +
+			kimchiRow + "\n" +
+			"\r\n")
+	p, _ := New(reader, s.converter, WithLeniency(Lenient))
+
+	var results []Result
+	for r := range p.Results(context.Background()) {
+		require.NoError(t, r.Err)
+		results = append(results, r)
+	}
+	require.NoError(t, p.Err())
+
+	require.Len(t, results, 3)
+	require.Equal(t, []Normalization{NormalizationStripCR}, results[0].Normalized)
+	require.Equal(t, []Normalization{NormalizationStripCR, NormalizationPadShort}, results[1].Normalized)
+	require.Empty(t, results[2].Normalized)
+
+	stats := p.Stats()
+	require.Equal(t, 3, stats.Records)
+	require.Equal(t, 1, stats.Blank)
+	require.Equal(t, map[Normalization]int{NormalizationStripCR: 2, NormalizationPadShort: 1}, stats.Normalized)
+}
@@ -2,10 +2,18 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"io"
 	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
 
+	"github.com/jessejohnston/ProductIngester/charset"
 	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/jessejohnston/ProductIngester/tax"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 )
@@ -14,22 +22,28 @@ const (
 	// RecordLength is the expected length of each flat-file record
 	RecordLength = 142
 
-	// TaxRate is the product tax rate.
-	TaxRate = 0.07775
-
 	// NumberFieldLength is the expected length of all number fields.
-	NumberFieldLength   = 8
+	NumberFieldLength = 8
 
 	// CurrencyFieldLength is the expected length of all currency fields.
 	CurrencyFieldLength = 8
 
 	// FlagsFieldLength is the expected length of all flag fields.
-	FlagsFieldLength    = 9
+	FlagsFieldLength = 9
+
+	// DefaultBatchSize is the default number of lines handed to a parsing worker at a time.
+	DefaultBatchSize = 64
 )
 
 var (
 	// ErrBadParameter is the error returned when invalid input is provided.
 	ErrBadParameter = errors.New("Invalid parameter")
+
+	// ErrZeroForX is the error returned when a split price is for a quantity of zero.
+	ErrZeroForX = errors.New("Zero for X quantity")
+
+	// DefaultTaxRate is the tax rate of taxable products when the parser has no tax policy.
+	DefaultTaxRate = decimal.New(7775, -5)
 )
 
 // Converter is the behavior of a type that converts fixed-length text values to other types.
@@ -41,147 +55,681 @@ type Converter interface {
 	ToFlags(text []byte) (product.Flags, error)
 }
 
+// TaxPolicy is the behavior of a type that provides the tax rate of taxable products.
+type TaxPolicy interface {
+	TaxRate(flags product.Flags) (decimal.Decimal, error)
+}
+
+// Option configures optional parser behavior.
+type Option func(*Parser) error
+
+// WithLayout configures the parser to read records with the given layout instead of DefaultLayout.
+func WithLayout(l Layout) Option {
+	return func(p *Parser) error {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+		p.layout = l
+		return nil
+	}
+}
+
+// WithTaxPolicy configures the parser to consult policy for the tax rate of each taxable record,
+// instead of using DefaultTaxRate. A record with no tax rate fails to parse.
+func WithTaxPolicy(policy TaxPolicy) Option {
+	return func(p *Parser) error {
+		if policy == nil {
+			return errors.WithStack(ErrBadParameter)
+		}
+		p.tax = policy
+		return nil
+	}
+}
+
+// WithUnitPricing configures the parser to calculate unit prices with the given rules, instead of
+// product.UnitPricingUS.
+func WithUnitPricing(u product.UnitPricing) Option {
+	return func(p *Parser) error {
+		if err := u.Validate(); err != nil {
+			return err
+		}
+		p.unitPricing = u
+		return nil
+	}
+}
+
+// WithCharset configures the parser to read input in the given charset, instead of charset.UTF8. Each field is
+// decoded to UTF-8 before it is converted, and a record with bytes that aren't valid in the charset fails to parse.
+// Input that starts with a UTF-8 byte order mark is read as UTF-8, whatever the configured charset.
+func WithCharset(c *charset.Charset) Option {
+	return func(p *Parser) error {
+		if c == nil {
+			return errors.WithStack(ErrBadParameter)
+		}
+		p.charset = c
+		return nil
+	}
+}
+
+// WithConcurrency configures the parser to convert lines on the given number of worker goroutines.
+// Results are still produced in input order. The default is a single worker.
+func WithConcurrency(workers int) Option {
+	return func(p *Parser) error {
+		if workers < 1 {
+			return errors.WithStack(ErrBadParameter)
+		}
+		p.workers = workers
+		return nil
+	}
+}
+
+// WithBatchSize configures the number of lines handed to a worker at a time. The default is DefaultBatchSize.
+func WithBatchSize(lines int) Option {
+	return func(p *Parser) error {
+		if lines < 1 {
+			return errors.WithStack(ErrBadParameter)
+		}
+		p.batchSize = lines
+		return nil
+	}
+}
+
+// WithRejects configures the parser to copy every line that fails to parse, verbatim, to rejects,
+// and to write a CSV report of the source, line number, column, field name and text, error code and error of each
+// failure to report.
+func WithRejects(rejects, report io.Writer) Option {
+	return func(p *Parser) error {
+		r, err := NewRejects(rejects, report)
+		if err != nil {
+			return err
+		}
+		p.rejects = r
+		return nil
+	}
+}
+
+// WithSharedRejects configures the parser to write the lines that fail to parse to a reject writer that other
+// runs may also write to.
+func WithSharedRejects(r *Rejects) Option {
+	return func(p *Parser) error {
+		if r == nil {
+			return errors.WithStack(ErrBadParameter)
+		}
+		p.rejects = r
+		return nil
+	}
+}
+
+// WithSource names the input, such as its file name, in the reject report.
+func WithSource(name string) Option {
+	return func(p *Parser) error {
+		p.source = name
+		return nil
+	}
+}
+
+// WithMaxErrors configures the parser to stop with ErrTooManyErrors as soon as more than max lines fail to parse.
+func WithMaxErrors(max int) Option {
+	return func(p *Parser) error {
+		if max < 0 {
+			return errors.WithStack(ErrBadParameter)
+		}
+		p.budget.maxErrors = max
+		return nil
+	}
+}
+
+// WithMaxErrorRate configures the parser to end with ErrTooManyErrors if more than percent of the lines
+// fail to parse. The rate is checked once the whole input has been read.
+func WithMaxErrorRate(percent float64) Option {
+	return func(p *Parser) error {
+		if percent < 0 || percent > 100 {
+			return errors.WithStack(ErrBadParameter)
+		}
+		p.budget.maxRate = percent
+		return nil
+	}
+}
+
+// WithStopOnError configures the parser to stop with ErrTooManyErrors at the first line that fails to parse.
+func WithStopOnError() Option {
+	return WithMaxErrors(0)
+}
+
 // Parser reads from an input source, producing parsed records in it's Output channel.
 type Parser struct {
 	src     io.Reader
 	convert Converter
-	records chan *product.Record
-	errors  chan error
-	done    chan bool
+	layout  Layout
+	fields  fields
+	tax     TaxPolicy
+	charset *charset.Charset
+	err     error
+
+	leniency Leniency
+
+	unitPricing product.UnitPricing
+
+	workers   int
+	batchSize int
+	source    string
+	rejects   *Rejects
+	budget    budget
+	stats     Stats
 }
 
 // New creates a new product parser.
-func New(input io.Reader, c Converter) (*Parser, error) {
+func New(input io.Reader, c Converter, opts ...Option) (*Parser, error) {
 	if input == nil || c == nil {
 		return nil, ErrBadParameter
 	}
 
-	return &Parser{
+	p := &Parser{
 		src:     input,
 		convert: c,
-		records: make(chan *product.Record),
-		errors:  make(chan error),
-		done:    make(chan bool),
-	}, nil
+		layout:  DefaultLayout(),
+		tax:     tax.Fixed{Rate: DefaultTaxRate},
+		charset: charset.UTF8,
+
+		leniency: DefaultLeniency,
+
+		unitPricing: product.UnitPricingUS,
+
+		workers:   1,
+		batchSize: DefaultBatchSize,
+		budget:    budget{maxErrors: -1, maxRate: -1},
+	}
+
+	for _, opt := range opts {
+		if opt == nil {
+			return nil, ErrBadParameter
+		}
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+
+	p.fields = p.layout.fields()
+
+	return p, nil
+}
+
+// Layout returns the record layout used by the parser.
+func (p *Parser) Layout() Layout {
+	return p.layout
+}
+
+// Result is the outcome of parsing a single line of input: either a Record or an Err.
+type Result struct {
+	// Line is the 1-based line number of the input.
+	Line   int
+	Record *product.Record
+	Err    error
+
+	// Normalized lists the normalizations applied to the line before parsing it.
+	Normalized []Normalization
+
+	// text is the line as read from the input, and ending is the newline that ended it, with any carriage return
+	// before it. The last line of the input may have no newline.
+	text   []byte
+	ending []byte
+
+	// blank is true for a line with no content, which isn't sent to the consumer.
+	blank bool
 }
 
 // Parse reads each line from the input and sends parsed records to the output channel.
 func (p *Parser) Parse() (<-chan *product.Record, <-chan error, <-chan bool) {
+	return p.ParseContext(context.Background())
+}
+
+// ParseContext is like Parse, but stops when ctx is cancelled or its deadline passes.
+// The output channels are closed when parsing stops, and Err reports why it stopped early.
+// If the input is a file, pipe or network connection it is closed on cancellation, to unblock a pending read.
+func (p *Parser) ParseContext(ctx context.Context) (<-chan *product.Record, <-chan error, <-chan bool) {
+	records := make(chan *product.Record)
+	errs := make(chan error)
+	done := make(chan bool)
+
+	results := p.Results(ctx)
+
+	go func() {
+		defer func() {
+			close(done)
+			close(records)
+			close(errs)
+		}()
+
+		for r := range results {
+			if r.Err != nil {
+				select {
+				case errs <- r.Err:
+				case <-ctx.Done():
+				}
+			} else {
+				select {
+				case records <- r.Record:
+				case <-ctx.Done():
+				}
+			}
+		}
+
+		// Results may have finished before the context ended, with sends to a departed consumer abandoned here.
+		if p.err == nil {
+			p.err = ctx.Err()
+		}
+		if p.err != nil {
+			return
+		}
+
+		select {
+		case done <- true:
+		case <-ctx.Done():
+			p.err = ctx.Err()
+		}
+	}()
+
+	return records, errs, done
+}
+
+// Results reads each line from the input, sending the result of parsing it to the returned channel in input order.
+// The channel is closed when parsing ends, after which Err reports whether it ended early.
+// Parsing stops when ctx is cancelled or its deadline passes; if the input is a file, pipe or network connection
+// it is closed on cancellation, to unblock a pending read. Any other input, such as a decompressor, is left for the
+// caller to close once the channel is closed. A consumer that stops reading early must cancel ctx.
+func (p *Parser) Results(ctx context.Context) <-chan Result {
+	results := make(chan Result)
+
 	// "go" runs p.execute() asynchronously so that the caller can start reading
-	// records and errors off the returned channels.
-	go p.execute()
+	// results off the returned channel.
+	go p.execute(ctx, results)
+
+	return results
+}
+
+// Stats returns a summary of the parse run. It is only valid after the output channels are closed.
+func (p *Parser) Stats() Stats {
+	return p.stats.copy()
+}
+
+// Err returns the error that stopped parsing early, if any. It is only valid after the output channels are closed.
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// batch is a run of consecutive input lines, parsed together by one worker.
+type batch struct {
+	seq     int
+	first   int
+	lines   [][]byte
+	endings [][]byte
+}
 
-	return p.records, p.errors, p.done
+// parsedBatch holds the results of parsing a batch.
+type parsedBatch struct {
+	seq     int
+	results []Result
 }
 
-func (p *Parser) execute() {
+func (p *Parser) execute(ctx context.Context, results chan<- Result) {
+	defer close(results)
+
+	stats := newStats()
+	input := &countingReader{r: p.src}
+	start := time.Now()
 	defer func() {
-		close(p.done)
-		close(p.records)
-		close(p.errors)
+		stats.Bytes = input.count()
+		stats.Elapsed = time.Since(start)
+		p.stats = stats
 	}()
 
-	scanner := bufio.NewScanner(p.src)
+	// Only the caller's context closes the input. The run's own cancellation, below, leaves the scanner to finish
+	// its current read, as the input may not be safe to close while it is being read.
+	scanned := make(chan struct{})
+	go unblockScan(ctx, scanned, p.src)
 
-	for row := 0; scanner.Scan(); row++ {
-		data := scanner.Bytes()
-		record, err := p.ParseRecord(row, data)
-		if err != nil {
-			log.Println(errors.WithStack(err))
-			p.errors <- err
-		} else {
-			p.records <- record
+	// The run is cancelled internally if it can't continue, such as when rejects can't be written.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var failure error
+
+	batches := make(chan batch, p.workers)
+	parsed := make(chan parsedBatch, p.workers)
+
+	scanErr := make(chan error, 1)
+	go func() {
+		err := p.scan(ctx, input, batches)
+		close(scanned)
+		scanErr <- err
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx, batches, parsed)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(parsed)
+	}()
+
+	// Workers finish batches out of order, so hold each one until all earlier batches have been sent.
+	pending := make(map[int][]Result)
+	next := 0
+
+	for b := range parsed {
+		pending[b.seq] = b.results
+		for {
+			batchResults, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			for _, r := range batchResults {
+				if failure != nil || ctx.Err() != nil {
+					break
+				}
+				stats.add(r)
+				if r.blank {
+					continue
+				}
+
+				if err := p.emit(ctx, results, r); err != nil {
+					failure = err
+					cancel()
+					break
+				}
+
+				if err := p.budget.check(stats.Records+stats.Errors, stats.Errors, false); err != nil {
+					failure = err
+					cancel()
+				}
+			}
+		}
+	}
+
+	if p.rejects != nil {
+		if err := p.rejects.Flush(); err != nil && failure == nil {
+			failure = err
+		}
+	}
+	if failure != nil {
+		p.err = failure
+		return
+	}
+
+	// A read error caused by closing the input on cancellation is reported as the context error.
+	if err := ctx.Err(); err != nil {
+		p.err = err
+		return
+	}
+	if err := <-scanErr; err != nil {
+		p.err = err
+		return
+	}
+	p.err = p.budget.check(stats.Records+stats.Errors, stats.Errors, true)
+}
+
+// unblockScan closes the input if the context ends while the scanner may be waiting on it, and the scan hasn't
+// completed. Only inputs whose pending read returns when they are closed, such as files, pipes and network
+// connections, are closed; closing a decompressor while it is being read is a data race.
+func unblockScan(ctx context.Context, scanned <-chan struct{}, input io.Reader) {
+	select {
+	case <-ctx.Done():
+		select {
+		case <-scanned:
+		default:
+			switch input.(type) {
+			case *os.File, *io.PipeReader, net.Conn:
+				input.(io.Closer).Close()
+			}
+		}
+	case <-scanned:
+	}
+}
+
+// emit sends a result to the consumer, first copying a failed line to the reject output.
+func (p *Parser) emit(ctx context.Context, results chan<- Result, r Result) error {
+	if r.Err != nil && p.rejects != nil {
+		if err := p.rejects.write(p.source, r, p.charset); err != nil {
+			return err
 		}
 	}
 
-	p.done <- true
+	select {
+	case results <- r:
+	case <-ctx.Done():
+	}
+	return nil
 }
 
+// scan reads lines from the input, sending them in batches of p.batchSize lines.
+func (p *Parser) scan(ctx context.Context, input io.Reader, batches chan<- batch) error {
+	defer close(batches)
+
+	// A byte order mark means the input is UTF-8. Workers read the charset after the first batch is sent.
+	buffered := bufio.NewReader(input)
+	if header, _ := buffered.Peek(len(charset.BOM)); string(header) == charset.BOM {
+		buffered.Discard(len(charset.BOM))
+		p.charset = charset.UTF8
+	}
+
+	scanner := bufio.NewScanner(buffered)
+	scanner.Split(scanLines(p.charset.Newlines()))
+	b := batch{first: 1}
+
+	send := func() bool {
+		select {
+		case batches <- b:
+			b = batch{seq: b.seq + 1, first: b.first + len(b.lines)}
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for scanner.Scan() {
+		// The scanner reuses its buffer, so each line is copied before handing it to a worker.
+		line := make([]byte, len(scanner.Bytes()))
+		copy(line, scanner.Bytes())
+		text, ending := splitLine(line, p.charset.Newlines())
+		b.lines = append(b.lines, text)
+		b.endings = append(b.endings, ending)
+
+		if len(b.lines) == p.batchSize && !send() {
+			return nil
+		}
+	}
+
+	if len(b.lines) > 0 && !send() {
+		return nil
+	}
+
+	return errors.WithStack(scanner.Err())
+}
+
+// scanLines returns a split function for lines ending in any of the newline bytes. Unlike bufio.ScanLines, each
+// line keeps its newline, so that a rejected line can be copied as it was read; splitLine separates them.
+func scanLines(newlines []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.IndexAny(data, string(newlines)); i >= 0 {
+			return i + 1, data[:i+1], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// splitLine splits a line into its text and its ending: the newline, if any, and a carriage return before it.
+func splitLine(line, newlines []byte) (text, ending []byte) {
+	end := len(line)
+	if end > 0 && bytes.IndexByte(newlines, line[end-1]) >= 0 {
+		end--
+	}
+	if end > 0 && line[end-1] == '\r' {
+		end--
+	}
+	return line[:end], line[end:]
+}
+
+// work parses batches of lines until there are no more batches or ctx ends.
+func (p *Parser) work(ctx context.Context, batches <-chan batch, parsed chan<- parsedBatch) {
+	for {
+		var b batch
+		var ok bool
+
+		select {
+		case b, ok = <-batches:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		results := make([]Result, len(b.lines))
+		for i, line := range b.lines {
+			if p.blank(line) {
+				results[i] = Result{Line: b.first + i, text: line, ending: b.endings[i], blank: true}
+				continue
+			}
+
+			normalized, applied := p.normalize(line, b.endings[i])
+			record, err := p.parseRecord(b.first+i, normalized)
+			if err != nil {
+				log.Println(errors.WithStack(err))
+			}
+			results[i] = Result{Line: b.first + i, Record: record, Err: err, Normalized: applied, text: line, ending: b.endings[i]}
+		}
+
+		select {
+		case parsed <- parsedBatch{seq: b.seq, results: results}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// blank returns true for a line with no content.
+func (p *Parser) blank(line []byte) bool {
+	if p.charset != charset.UTF8 {
+		line = p.charset.Decode(line)
+	}
+	return len(bytes.TrimSpace(line)) == 0
+}
+
+// ParseRecord parses a single line of text into a product record, using the parser's layout and leniency. A line
+// ending, with any carriage return, is removed first, as it is from each line read by Results.
 func (p *Parser) ParseRecord(row int, text []byte) (*product.Record, error) {
-	if len(text) != RecordLength {
-		return nil, errors.WithStack(ErrBadParameter)
+	text, _ = p.normalize(splitLine(text, p.charset.Newlines()))
+	return p.parseRecord(row, text)
+}
+
+// parseRecord parses a normalized line.
+func (p *Parser) parseRecord(row int, text []byte) (*product.Record, error) {
+	if len(text) != p.layout.Length {
+		return nil, recordLengthError(row, len(text), p.layout.Length)
+	}
+	if i := p.charset.Invalid(text); i >= 0 {
+		return nil, encodingError(row, p.layout, text, i)
 	}
 
+	f := &p.fields
 	record := &product.Record{}
 	var err error
 
-	fragment := text[0:8]
+	fragment := p.slice(text, f.id)
 	record.ID, err = p.convert.ToNumber(fragment)
 	if err != nil {
-		return nil, NewParserError(row, 0, fragment, "Error parsing ID", err)
+		return nil, fieldError(row, f.id, fragment, "Error parsing ID", err)
 	}
 
-	fragment = text[9:68]
+	fragment = p.slice(text, f.description)
 	record.Description = p.convert.ToString(fragment)
 
-	fragment = text[69:77]
+	fragment = p.slice(text, f.price)
 	singularPrice, err := p.convert.ToCurrency(fragment)
 	if err != nil {
-		return nil, NewParserError(row, 69, fragment, "Error parsing singular price", err)
+		return nil, fieldError(row, f.price, fragment, "Error parsing singular price", err)
 	}
 
 	// If singular price is zero, read the split price and use it instead.
-	if singularPrice.Equal(decimal.Zero) {
-		fragment = text[87:95]
+	if singularPrice.Equal(decimal.Zero) && f.splitPrice.Present() {
+		fragment = p.slice(text, f.splitPrice)
 		splitPrice, err := p.convert.ToCurrency(fragment)
 		if err != nil {
-			return nil, NewParserError(row, 87, fragment, "Error parsing split price", err)
+			return nil, fieldError(row, f.splitPrice, fragment, "Error parsing split price", err)
 		}
 
-		fragment = text[105:113]
-		forX, err := p.convert.ToNumber(fragment)
+		fragment = p.slice(text, f.forX)
+		forX, err := p.number(fragment, f.forX)
 		if err != nil {
-			return nil, NewParserError(row, 105, fragment, "Error parsing for X", err)
+			return nil, fieldError(row, f.forX, fragment, "Error parsing for X", err)
 		}
 		if forX == 0 {
-			return nil, NewParserError(row, 105, fragment, "Error calculating split price (zero for X)", err)
+			return nil, fieldError(row, f.forX, fragment, "Error calculating split price (zero for X)", ErrZeroForX)
 		}
 
 		// Round to 4 decimal places, half down
 		record.Price = splitPrice.Div(decimal.New(int64(forX), 0)).RoundBank(4)
+		record.SplitPrice = splitPrice
+		record.SplitQuantity = forX
 	} else {
 		record.Price = singularPrice
 	}
 
-	fragment = text[78:86]
-	singularPromoPrice, err := p.convert.ToCurrency(fragment)
+	fragment = p.slice(text, f.promoPrice)
+	singularPromoPrice, err := p.currency(fragment, f.promoPrice)
 	if err != nil {
-		return nil, NewParserError(row, 78, fragment, "Error parsing singular promotional price", err)
+		return nil, fieldError(row, f.promoPrice, fragment, "Error parsing singular promotional price", err)
 	}
 
 	// If singular promo price is zero, read the split promo price and use it instead.
-	if singularPromoPrice.Equal(decimal.Zero) {
-		fragment = text[96:104]
+	if singularPromoPrice.Equal(decimal.Zero) && f.splitPromoPrice.Present() {
+		fragment = p.slice(text, f.splitPromoPrice)
 		splitPromoPrice, err := p.convert.ToCurrency(fragment)
 		if err != nil {
-			return nil, NewParserError(row, 96, text[96:104], "Error parsing split promo price", err)
+			return nil, fieldError(row, f.splitPromoPrice, fragment, "Error parsing split promo price", err)
 		}
 
 		if splitPromoPrice.GreaterThan(decimal.Zero) {
-			fragment = text[114:122]
-			promoForX, err := p.convert.ToNumber(fragment)
+			fragment = p.slice(text, f.promoForX)
+			promoForX, err := p.number(fragment, f.promoForX)
 			if err != nil {
-				return nil, NewParserError(row, 114, fragment, "Error parsing promo for X", err)
+				return nil, fieldError(row, f.promoForX, fragment, "Error parsing promo for X", err)
 			}
 			if promoForX == 0 {
-				return nil, NewParserError(row, 114, fragment, "Error calculating promo split price (zero for X)", err)
+				return nil, fieldError(row, f.promoForX, fragment, "Error calculating promo split price (zero for X)", ErrZeroForX)
 			}
-	
+
 			// Round to 4 decimal places, half down
 			record.PromoPrice = splitPromoPrice.Div(decimal.New(int64(promoForX), 0)).RoundBank(4)
+			record.PromoSplitPrice = splitPromoPrice
+			record.PromoSplitQuantity = promoForX
 		}
 	} else {
 		record.PromoPrice = singularPromoPrice
 	}
 
-	record.DisplayPrice = "$" + record.Price.StringFixed(2)
-	record.PromoDisplayPrice = "$" + record.PromoPrice.StringFixed(2)
+	record.DisplayPrice = product.FormatPrice(record.Price, record.SplitPrice, record.SplitQuantity)
+	record.PromoDisplayPrice = product.FormatPrice(record.PromoPrice, record.PromoSplitPrice, record.PromoSplitQuantity)
 
-	fragment = text[123:132]
+	fragment = p.slice(text, f.flags)
 	flags, err := p.convert.ToFlags(fragment)
 	if err != nil {
-		return nil, NewParserError(row, 123, fragment, "Error parsing flags", err)
+		return nil, fieldError(row, f.flags, fragment, "Error parsing flags", err)
 	}
+	record.Flags = flags
 
 	if flags.PerWeight() {
 		record.Unit = product.UnitPound
@@ -190,12 +738,56 @@ func (p *Parser) ParseRecord(row int, text []byte) (*product.Record, error) {
 	}
 
 	if flags.Taxable() {
-		record.TaxRate = decimal.NewFromFloat32(TaxRate)
+		record.TaxRate, err = p.tax.TaxRate(flags)
+		if err != nil {
+			return nil, fieldError(row, f.flags, fragment, "Error finding tax rate", err)
+		}
 	} else {
 		record.TaxRate = decimal.Zero
 	}
 
-	record.Size = p.convert.ToString(text[133:142])
+	record.Size = p.convert.ToString(p.slice(text, f.size))
+
+	// An unrecognized size is kept for display, without a quantity.
+	if quantity, err := product.ParseSize(record.Size); err == nil && !quantity.IsZero() {
+		record.Quantity = quantity
+
+		// A size the unit pricing has no basis for is kept without unit prices.
+		_ = record.SetUnitPrices(p.unitPricing)
+	}
 
 	return record, nil
 }
+
+// number converts an optional number field, which is zero when the layout doesn't include it.
+func (p *Parser) number(text []byte, f Field) (int, error) {
+	if !f.Present() {
+		return 0, nil
+	}
+	return p.convert.ToNumber(text)
+}
+
+// currency converts an optional currency field, which is zero when the layout doesn't include it.
+func (p *Parser) currency(text []byte, f Field) (decimal.Decimal, error) {
+	if !f.Present() {
+		return decimal.Zero, nil
+	}
+	return p.convert.ToCurrency(text)
+}
+
+// slice returns the text of a field decoded to UTF-8, or nil if the layout doesn't include it.
+func (p *Parser) slice(text []byte, f Field) []byte {
+	fragment := slice(text, f)
+	if fragment == nil || p.charset == charset.UTF8 {
+		return fragment
+	}
+	return p.charset.Decode(fragment)
+}
+
+// slice returns the text of a field, or nil if the layout doesn't include it.
+func slice(text []byte, f Field) []byte {
+	if !f.Present() {
+		return nil
+	}
+	return text[f.Start:f.End()]
+}
@@ -1,14 +1,26 @@
 package parser
 
 import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/jessejohnston/ProductIngester/charset"
 	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/jessejohnston/ProductIngester/tax"
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/text/encoding/charmap"
 )
 
 type parserTestSuite struct {
@@ -157,6 +169,81 @@ func (s *parserTestSuite) Test_ParseRecord_PerWeightFlagNotSet_HasEachUnitOfMeas
 	require.Equal(t, product.UnitEach, r.Unit)
 }
 
+func (s *parserTestSuite) Test_ParseRecord_Size_HasQuantity() {
+	t := s.T()
+
+	reader := strings.NewReader("the file")
+	p, _ := New(reader, s.converter)
+
+	row := []byte("14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN   12x12oz")
+	r, err := p.ParseRecord(1, row)
+	require.NoError(t, err)
+
+	require.Equal(t, "12x12oz", r.Size)
+	require.Equal(t, 12, r.Quantity.Pack)
+	require.True(t, r.Quantity.Amount.Equal(decimal.New(12, 0)))
+	require.Equal(t, product.UnitOunce, r.Quantity.Unit)
+}
+
+func (s *parserTestSuite) Test_ParseRecord_Size_HasUnitPrices() {
+	t := s.T()
+
+	reader := strings.NewReader("the file")
+	p, _ := New(reader, s.converter)
+
+	row := []byte("50133333 Fuji Apples (Organic)                                       00000349 00000000 00000000 00000000 00000000 00000000 NNYNNNNNN        lb")
+	r, err := p.ParseRecord(1, row)
+	require.NoError(t, err)
+
+	require.True(t, r.UnitPrice.Equal(decimal.New(218, -3)))
+	require.True(t, r.PromoUnitPrice.IsZero())
+	require.Equal(t, product.UnitOunce, r.UnitPriceBasis.Unit)
+}
+
+func (s *parserTestSuite) Test_ParseRecord_UnitPricing_UsesJurisdictionRules() {
+	t := s.T()
+
+	reader := strings.NewReader("the file")
+	p, err := New(reader, s.converter, WithUnitPricing(product.UnitPricingEU))
+	require.NoError(t, err)
+
+	row := []byte("50133333 Fuji Apples (Organic)                                       00000349 00000000 00000000 00000000 00000000 00000000 NNYNNNNNN        lb")
+	r, err := p.ParseRecord(1, row)
+	require.NoError(t, err)
+
+	require.True(t, r.UnitPrice.Equal(decimal.New(769, -2)))
+	require.Equal(t, product.UnitKilogram, r.UnitPriceBasis.Unit)
+}
+
+func (s *parserTestSuite) Test_ParseRecord_UnrecognizedSize_HasNoQuantity() {
+	t := s.T()
+
+	reader := strings.NewReader("the file")
+	p, _ := New(reader, s.converter)
+
+	row := []byte("14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN    jumbo!")
+	r, err := p.ParseRecord(1, row)
+	require.NoError(t, err)
+
+	require.Equal(t, "jumbo!", r.Size)
+	require.True(t, r.Quantity.IsZero())
+	require.True(t, r.UnitPriceBasis.IsZero())
+}
+
+func (s *parserTestSuite) Test_ParseRecord_AgeRestrictedFlagSet_HasAgeRestrictedFlag() {
+	t := s.T()
+
+	reader := strings.NewReader("the file")
+	p, _ := New(reader, s.converter)
+
+	row := []byte("40123401 Marlboro Cigarettes                                         00001000 00000549 00000000 00000000 00000000 00000000 YNNNNNNNN          ")
+	r, err := p.ParseRecord(1, row)
+	require.NoError(t, err)
+
+	require.True(t, r.Flags.AgeRestricted())
+	require.Equal(t, product.FlagAgeRestricted, r.Flags)
+}
+
 func (s *parserTestSuite) Test_ParseRecord_PerWeightFlagSet_HasPoundUnitOfMeasure() {
 	t := s.T()
 
@@ -193,8 +280,47 @@ func (s *parserTestSuite) Test_ParseRecord_TaxableFlagSet_HasTaxRate() {
 	r, err := p.ParseRecord(1, row)
 	require.NoError(t, err)
 
-	expectedTaxRate := decimal.NewFromFloat32(TaxRate)
-	require.Equal(t, expectedTaxRate, r.TaxRate)
+	expectedTaxRate, _ := decimal.NewFromString("0.07775")
+	require.True(t, r.TaxRate.Equal(expectedTaxRate))
+}
+
+func (s *parserTestSuite) Test_ParseRecord_TaxPolicy_HasPolicyRate() {
+	t := s.T()
+
+	table := &tax.Table{Rules: []tax.Rule{
+		{Region: "WA", Rate: decimal.New(65, -3)},
+		{Store: "0042", Rate: decimal.New(101, -3)},
+	}}
+
+	reader := strings.NewReader("the file")
+	p, err := New(reader, s.converter, WithTaxPolicy(table.Policy("0042", "WA", time.Now())))
+	require.NoError(t, err)
+
+	row := []byte("14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN   12x12oz")
+	r, err := p.ParseRecord(1, row)
+	require.NoError(t, err)
+	require.True(t, r.TaxRate.Equal(decimal.New(101, -3)))
+}
+
+func (s *parserTestSuite) Test_ParseRecord_TaxPolicyWithoutRate_ReturnsError() {
+	t := s.T()
+
+	table := &tax.Table{Rules: []tax.Rule{{Region: "WA", Rate: decimal.New(65, -3)}}}
+
+	reader := strings.NewReader("the file")
+	p, _ := New(reader, s.converter, WithTaxPolicy(table.Policy("0042", "OR", time.Now())))
+
+	row := []byte("14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN   12x12oz")
+	_, err := p.ParseRecord(1, row)
+	require.Error(t, err)
+	require.Equal(t, tax.ErrNoRate, errors.Cause(err))
+	require.Equal(t, CodeNoTaxRate, CodeOf(err))
+}
+
+func (s *parserTestSuite) Test_New_NilTaxPolicy_ReturnsError() {
+	_, err := New(strings.NewReader("the file"), s.converter, WithTaxPolicy(nil))
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
 }
 
 func (s *parserTestSuite) Test_Parse_ReturnsAllRecords() {
@@ -269,3 +395,355 @@ finished:
 	require.Equal(t, 50133333, results[2].ID)
 	require.Len(t, errs, 1)
 }
+
+func (s *parserTestSuite) Test_ParseContext_Cancelled_ClosesChannels_ReportsError() {
+	t := s.T()
+
+	reader := strings.NewReader(
+		"80000001 Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz\n" +
+			"14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN   12x12oz\n")
+	p, _ := New(reader, s.converter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	records, errors, done := p.ParseContext(ctx)
+
+	// Stop reading after the first record, as a consumer that gave up would.
+	r := <-records
+	require.Equal(t, 80000001, r.ID)
+	cancel()
+
+	for range records {
+	}
+	for range errors {
+	}
+	_, ok := <-done
+	require.False(t, ok)
+	require.Equal(t, context.Canceled, p.Err())
+}
+
+func (s *parserTestSuite) Test_ParseContext_DeadlinePassed_UnblocksPendingRead() {
+	t := s.T()
+
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	p, _ := New(reader, s.converter)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	records, errors, done := p.ParseContext(ctx)
+
+	select {
+	case _, ok := <-done:
+		require.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("parser did not stop at the deadline")
+	}
+
+	_, ok := <-records
+	require.False(t, ok)
+	_, ok = <-errors
+	require.False(t, ok)
+	require.Equal(t, context.DeadlineExceeded, p.Err())
+}
+
+// closeRecorder is an input that records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (s *parserTestSuite) Test_UnblockScan_Cancelled_ClosesFile() {
+	t := s.T()
+
+	input, writer, err := os.Pipe()
+	require.NoError(t, err)
+	defer writer.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	unblockScan(ctx, make(chan struct{}), input)
+	_, err = input.Read(make([]byte, 1))
+	require.True(t, stderrors.Is(err, os.ErrClosed))
+}
+
+func (s *parserTestSuite) Test_UnblockScan_Cancelled_LeavesOtherInputOpen() {
+	input := &closeRecorder{Reader: strings.NewReader("the file")}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	unblockScan(ctx, make(chan struct{}), input)
+	require.False(s.T(), input.closed)
+}
+
+func (s *parserTestSuite) Test_UnblockScan_ScanCompleted_DoesNotCloseInput() {
+	t := s.T()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scanned := make(chan struct{})
+	close(scanned)
+
+	// Both cases are ready, as they are when a run returns, so try often enough to catch a random choice.
+	for i := 0; i < 100; i++ {
+		input, writer, err := os.Pipe()
+		require.NoError(t, err)
+		unblockScan(ctx, scanned, input)
+		require.NoError(t, input.Close())
+		writer.Close()
+	}
+}
+
+func (s *parserTestSuite) Test_Parse_Completed_NoError() {
+	t := s.T()
+
+	reader := strings.NewReader("80000001 Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz")
+	p, _ := New(reader, s.converter)
+
+	records, _, done := p.Parse()
+	<-records
+	<-done
+	require.NoError(t, p.Err())
+}
+
+func (s *parserTestSuite) Test_Results_ReturnsRecordsAndErrorsInOrder() {
+	t := s.T()
+
+	reader := strings.NewReader(
+		"80000001 Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz\n" +
+			"40123401 Marlboro Cigare\n" +
+			"14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN   12x12oz\n" +
+			"50133333 Fuji Apples (Organic)                                       00000349 00000000 00000000 00000000 00000000 00000000 NNYNNNNNX        lb")
+	p, _ := New(reader, s.converter)
+
+	var results []Result
+	for r := range p.Results(context.Background()) {
+		results = append(results, r)
+	}
+	require.NoError(t, p.Err())
+
+	require.Len(t, results, 4)
+	for i, r := range results {
+		require.Equal(t, i+1, r.Line)
+	}
+
+	require.NoError(t, results[0].Err)
+	require.Equal(t, 80000001, results[0].Record.ID)
+	require.Error(t, results[1].Err)
+	require.Nil(t, results[1].Record)
+	require.NoError(t, results[2].Err)
+	require.Equal(t, 14963801, results[2].Record.ID)
+	require.Error(t, results[3].Err)
+	require.Nil(t, results[3].Record)
+}
+
+func (s *parserTestSuite) Test_Results_Cancelled_ClosesChannel_ReportsError() {
+	t := s.T()
+
+	reader := strings.NewReader(
+		"80000001 Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz\n" +
+			"14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN   12x12oz\n")
+	p, _ := New(reader, s.converter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := p.Results(ctx)
+
+	r := <-results
+	require.Equal(t, 1, r.Line)
+	cancel()
+
+	for range results {
+	}
+	require.Equal(t, context.Canceled, p.Err())
+}
+
+func (s *parserTestSuite) Test_NewParser_BadConcurrency_ReturnsError() {
+	t := s.T()
+
+	_, err := New(strings.NewReader("the file"), s.converter, WithConcurrency(0))
+	require.Error(t, err)
+	require.Equal(t, ErrBadParameter, errors.Cause(err))
+}
+
+func (s *parserTestSuite) Test_NewParser_BadBatchSize_ReturnsError() {
+	t := s.T()
+
+	_, err := New(strings.NewReader("the file"), s.converter, WithBatchSize(0))
+	require.Error(t, err)
+	require.Equal(t, ErrBadParameter, errors.Cause(err))
+}
+
+func (s *parserTestSuite) Test_Results_Concurrent_PreservesInputOrder() {
+	t := s.T()
+
+	const count = 1000
+	var input strings.Builder
+	for i := 0; i < count; i++ {
+		if i%7 == 3 {
+			input.WriteString("40123401 Marlboro Cigare\n")
+			continue
+		}
+		fmt.Fprintf(&input, "%08d Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz\n", i)
+	}
+
+	p, _ := New(strings.NewReader(input.String()), s.converter, WithConcurrency(8), WithBatchSize(5))
+
+	line := 0
+	for r := range p.Results(context.Background()) {
+		line++
+		require.Equal(t, line, r.Line)
+		if (line-1)%7 == 3 {
+			require.Error(t, r.Err)
+		} else {
+			require.NoError(t, r.Err)
+			require.Equal(t, line-1, r.Record.ID)
+		}
+	}
+	require.NoError(t, p.Err())
+	require.Equal(t, count, line)
+}
+
+func Benchmark_Parse(b *testing.B) {
+	converter, _ := product.NewConverter(NumberFieldLength, CurrencyFieldLength, FlagsFieldLength)
+
+	sample, err := ioutil.ReadFile("../cmd/ingester/input-sample.txt")
+	require.NoError(b, err)
+	input := bytes.Repeat(sample, 25000)
+
+	// Silence the per-row error log while benchmarking.
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				p, _ := New(bytes.NewReader(input), converter, WithConcurrency(workers))
+				for range p.Results(context.Background()) {
+				}
+				require.NoError(b, p.Err())
+			}
+		})
+	}
+}
+
+func (s *parserTestSuite) Test_ParseRecord_SplitPrice_KeepsSplitPriceAndQuantity() {
+	t := s.T()
+
+	p, _ := New(strings.NewReader("the file"), s.converter)
+
+	row := []byte("14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN   12x12oz")
+	r, err := p.ParseRecord(1, row)
+	require.NoError(t, err)
+
+	require.True(t, r.IsSplitPrice())
+	require.True(t, r.SplitPrice.Equals(decimal.New(1300, -2)))
+	require.Equal(t, 2, r.SplitQuantity)
+	require.Equal(t, "2/$13.00", r.DisplayPrice)
+
+	require.False(t, r.IsSplitPromoPrice())
+	require.Equal(t, 0, r.PromoSplitQuantity)
+	require.Equal(t, "$5.49", r.PromoDisplayPrice)
+}
+
+func (s *parserTestSuite) Test_ParseRecord_SplitPromoPrice_KeepsSplitPromoPriceAndQuantity() {
+	t := s.T()
+
+	p, _ := New(strings.NewReader("the file"), s.converter)
+
+	row := []byte("14963801 Generic Soda 12-pack                                        00000549 00000000 00000000 00001000 00000000 00000002 NNNNYNNNN   12x12oz")
+	r, err := p.ParseRecord(1, row)
+	require.NoError(t, err)
+
+	require.False(t, r.IsSplitPrice())
+	require.Equal(t, "$5.49", r.DisplayPrice)
+
+	require.True(t, r.IsSplitPromoPrice())
+	require.True(t, r.PromoSplitPrice.Equals(decimal.New(1000, -2)))
+	require.Equal(t, 2, r.PromoSplitQuantity)
+	require.Equal(t, "2/$10.00", r.PromoDisplayPrice)
+}
+
+// kimchiRow is a record whose description can be replaced by one of up to 26 bytes.
+const kimchiRow = "80000001 Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz"
+
+func withDescription(description string) []byte {
+	return []byte(strings.Replace(kimchiRow, "Kimchi-flavored white rice", description+strings.Repeat(" ", 26-len(description)), 1))
+}
+
+func (s *parserTestSuite) Test_New_NilCharset_ReturnsError() {
+	_, err := New(strings.NewReader("the file"), s.converter, WithCharset(nil))
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *parserTestSuite) Test_ParseRecord_Windows1252_DecodesFields() {
+	t := s.T()
+
+	p, _ := New(strings.NewReader("the file"), s.converter, WithCharset(charset.Windows1252))
+
+	r, err := p.ParseRecord(1, withDescription("Jalape\xf1o Peppers"))
+	require.NoError(t, err)
+	require.Equal(t, "Jalapeño Peppers", r.Description)
+}
+
+func (s *parserTestSuite) Test_ParseRecord_InvalidUTF8_ReturnsBadEncodingError() {
+	t := s.T()
+
+	p, _ := New(strings.NewReader("the file"), s.converter)
+
+	_, err := p.ParseRecord(1, withDescription("Jalape\xf1o Peppers"))
+	require.Error(t, err)
+	require.Equal(t, CodeBadEncoding, CodeOf(err))
+	require.Equal(t, charset.ErrBadEncoding, errors.Cause(err))
+
+	var e Error
+	require.True(t, stderrors.As(err, &e))
+	require.Equal(t, FieldDescription, e.FieldName())
+}
+
+func (s *parserTestSuite) Test_Results_EBCDIC_DecodesRecords() {
+	t := s.T()
+
+	var input []byte
+	for _, row := range [][]byte{withDescription("Rice"), withDescription("Soda")} {
+		encoded, err := charmap.CodePage037.NewEncoder().Bytes(row)
+		require.NoError(t, err)
+		input = append(append(input, encoded...), 0x25)
+	}
+
+	p, _ := New(bytes.NewReader(input), s.converter, WithCharset(charset.EBCDIC))
+
+	var records []*product.Record
+	for r := range p.Results(context.Background()) {
+		require.NoError(t, r.Err)
+		records = append(records, r.Record)
+	}
+	require.NoError(t, p.Err())
+
+	require.Len(t, records, 2)
+	require.Equal(t, 80000001, records[0].ID)
+	require.Equal(t, "Soda", records[1].Description)
+	require.True(t, records[1].Price.Equal(decimal.New(567, -2)))
+	require.Equal(t, "18oz", records[1].Size)
+}
+
+func (s *parserTestSuite) Test_Results_ByteOrderMark_ReadsUTF8() {
+	t := s.T()
+
+	input := charset.BOM + string(withDescription("Jalapeño Peppers")) + "\n"
+	p, _ := New(strings.NewReader(input), s.converter, WithCharset(charset.Windows1252))
+
+	var records []*product.Record
+	for r := range p.Results(context.Background()) {
+		require.NoError(t, r.Err)
+		records = append(records, r.Record)
+	}
+	require.Len(t, records, 1)
+	require.Equal(t, "Jalapeño Peppers", records[0].Description)
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/jessejohnston/ProductIngester/charset"
+	"github.com/pkg/errors"
+)
+
+// rejectHeader is the header row of the reject report.
+var rejectHeader = []string{"source", "line", "column", "name", "field", "code", "error"}
+
+// Rejects copies failed lines to a reject file, and describes each failure in a CSV report. It can be shared by
+// several runs, such as one per file of a zip archive, which share the report's header row, and whose rows are
+// told apart by the source configured with WithSource.
+type Rejects struct {
+	mu     sync.Mutex
+	lines  io.Writer
+	report *csv.Writer
+	header bool
+}
+
+// NewRejects returns a reject writer that copies failed lines, verbatim, to lines, and writes a CSV report of
+// the source, line number, column, field name and text, error code and error of each failure to report.
+func NewRejects(lines, report io.Writer) (*Rejects, error) {
+	if lines == nil || report == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+	return &Rejects{
+		lines:  lines,
+		report: csv.NewWriter(report),
+	}, nil
+}
+
+// write records a failed result of a line read from source in a charset. The line keeps the ending it was read
+// with, so the reject file can be parsed again in the same charset; a last line without a newline gets the
+// charset's.
+func (w *Rejects) write(source string, r Result, cs *charset.Charset) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	line := append(append([]byte{}, r.text...), r.ending...)
+	if len(r.ending) == 0 || r.ending[len(r.ending)-1] == '\r' {
+		line = append(line, cs.Newline())
+	}
+	if _, err := w.lines.Write(line); err != nil {
+		return errors.Wrap(err, "Error writing rejected line")
+	}
+
+	if !w.header {
+		if err := w.report.Write(rejectHeader); err != nil {
+			return errors.Wrap(err, "Error writing reject report")
+		}
+		w.header = true
+	}
+
+	row := []string{source, strconv.Itoa(r.Line), "", "", "", string(CodeOf(r.Err)), r.Err.Error()}
+	if e, ok := r.Err.(Error); ok {
+		row[2] = strconv.Itoa(e.Column())
+		row[3] = e.FieldName()
+		row[4] = string(e.Field())
+		row[6] = fmt.Sprintf("%s: %v", e.Message(), e.Unwrap())
+	}
+
+	if err := w.report.Write(row); err != nil {
+		return errors.Wrap(err, "Error writing reject report")
+	}
+	return nil
+}
+
+// Flush writes any buffered report rows.
+func (w *Rejects) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.report.Flush()
+	return errors.Wrap(w.report.Error(), "Error writing reject report")
+}
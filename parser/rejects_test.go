@@ -0,0 +1,161 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/charset"
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/text/encoding/charmap"
+)
+
+type rejectsTestSuite struct {
+	suite.Suite
+	converter Converter
+}
+
+func Test_Rejects(t *testing.T) {
+	s := new(rejectsTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *rejectsTestSuite) SetupSuite() {
+	s.converter, _ = product.NewConverter(NumberFieldLength, CurrencyFieldLength, FlagsFieldLength)
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func (s *rejectsTestSuite) Test_NewParser_NoRejectWriter_ReturnsError() {
+	_, err := New(strings.NewReader("the file"), s.converter, WithRejects(nil, &bytes.Buffer{}))
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *rejectsTestSuite) Test_Results_WritesFailedLinesAndReport() {
+	t := s.T()
+
+	reader := strings.NewReader(
+		"80000001 Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz\n" +
+			"40123401 Marlboro Cigare\n" +
+			"14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN   12x12oz\n" +
+			"50133333 Fuji Apples (Organic)                                       00000349 00000000 00000000 00000000 00000000 00000000 NNYNNNNNX        lb")
+
+	var rejects, report bytes.Buffer
+	p, err := New(reader, s.converter, WithRejects(&rejects, &report))
+	require.NoError(t, err)
+
+	for range p.Results(context.Background()) {
+	}
+	require.NoError(t, p.Err())
+
+	require.Equal(t,
+		"40123401 Marlboro Cigare\n"+
+			"50133333 Fuji Apples (Organic)                                       00000349 00000000 00000000 00000000 00000000 00000000 NNYNNNNNX        lb\n",
+		rejects.String())
+
+	require.Equal(t,
+		"source,line,column,name,field,code,error\n"+
+			",2,0,,,bad_length,\"Unexpected record length 24, expected 142: Invalid parameter\"\n"+
+			",4,123,flags,NNYNNNNNX,bad_format,Error parsing flags: Bad format\n",
+		report.String())
+}
+
+func (s *rejectsTestSuite) Test_Results_EBCDIC_RejectsParseAgain() {
+	t := s.T()
+
+	encode := func(text string) []byte {
+		encoded, err := charmap.CodePage037.NewEncoder().Bytes([]byte(text))
+		require.NoError(t, err)
+		return encoded
+	}
+
+	// Lines end with NL, then LF, then nothing.
+	var input []byte
+	input = append(append(input, encode("40123401 Marlboro Cigare")...), 0x15)
+	input = append(append(input, encode(string(withDescription("Rice")))...), 0x25)
+	input = append(input, encode("40123402 Marlboro Light")...)
+	var rejects, report bytes.Buffer
+	p, err := New(bytes.NewReader(input), s.converter, WithCharset(charset.EBCDIC), WithRejects(&rejects, &report))
+	require.NoError(t, err)
+	for range p.Results(context.Background()) {
+	}
+	require.NoError(t, p.Err())
+
+	want := append(append(encode("40123401 Marlboro Cigare"), 0x15), append(encode("40123402 Marlboro Light"), 0x25)...)
+	require.Equal(t, want, rejects.Bytes())
+
+	p, err = New(bytes.NewReader(rejects.Bytes()), s.converter, WithCharset(charset.EBCDIC))
+	require.NoError(t, err)
+	var lines []int
+	for r := range p.Results(context.Background()) {
+		require.Equal(t, CodeBadLength, CodeOf(r.Err))
+		lines = append(lines, r.Line)
+	}
+	require.Equal(t, []int{1, 2}, lines)
+}
+
+func (s *rejectsTestSuite) Test_Results_NoFailures_WritesNothing() {
+	t := s.T()
+
+	reader := strings.NewReader("80000001 Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz\n")
+
+	var rejects, report bytes.Buffer
+	p, _ := New(reader, s.converter, WithRejects(&rejects, &report))
+
+	for range p.Results(context.Background()) {
+	}
+	require.NoError(t, p.Err())
+	require.Empty(t, rejects.String())
+	require.Empty(t, report.String())
+}
+
+func (s *rejectsTestSuite) Test_Results_RejectWriteFails_StopsWithError() {
+	t := s.T()
+
+	reader := strings.NewReader("40123401 Marlboro Cigare\n")
+
+	p, _ := New(reader, s.converter, WithRejects(failingWriter{}, &bytes.Buffer{}))
+
+	for range p.Results(context.Background()) {
+	}
+	require.Error(t, p.Err())
+	require.Contains(t, p.Err().Error(), "disk full")
+}
+
+func (s *rejectsTestSuite) Test_Results_SharedRejects_WritesOneReportWithSources() {
+	t := s.T()
+
+	var lines, report bytes.Buffer
+	rejects, err := NewRejects(&lines, &report)
+	require.NoError(t, err)
+
+	for _, source := range []string{"catalogs.zip/east.txt", "catalogs.zip/west.txt"} {
+		p, err := New(strings.NewReader("40123401 Marlboro Cigare\n"), s.converter, WithSharedRejects(rejects), WithSource(source))
+		require.NoError(t, err)
+		for range p.Results(context.Background()) {
+		}
+		require.NoError(t, p.Err())
+	}
+
+	require.Equal(t, "40123401 Marlboro Cigare\n40123401 Marlboro Cigare\n", lines.String())
+	require.Equal(t,
+		"source,line,column,name,field,code,error\n"+
+			"catalogs.zip/east.txt,1,0,,,bad_length,\"Unexpected record length 24, expected 142: Invalid parameter\"\n"+
+			"catalogs.zip/west.txt,1,0,,,bad_length,\"Unexpected record length 24, expected 142: Invalid parameter\"\n",
+		report.String())
+}
+
+func (s *rejectsTestSuite) Test_NewParser_NilSharedRejects_ReturnsError() {
+	_, err := New(strings.NewReader("the file"), s.converter, WithSharedRejects(nil))
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
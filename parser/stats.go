@@ -0,0 +1,158 @@
+package parser
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/product"
+)
+
+// Stats summarizes a parse run.
+type Stats struct {
+	// Rows is the number of lines read, including blank lines.
+	Rows int `json:"rows"`
+
+	// Records is the number of lines parsed into records.
+	Records int `json:"records"`
+
+	// Errors is the number of lines that failed to parse.
+	Errors int `json:"errors"`
+
+	// Blank is the number of lines with no content, which are skipped.
+	Blank int `json:"blank"`
+
+	// SingularPrice and SplitPrice count the records priced by a singular or split price.
+	SingularPrice int `json:"singular_price"`
+	SplitPrice    int `json:"split_price"`
+
+	// SingularPromoPrice and SplitPromoPrice count the records with a singular or split promotional price.
+	SingularPromoPrice int `json:"singular_promo_price"`
+	SplitPromoPrice    int `json:"split_promo_price"`
+
+	// Taxable and PerWeight count the records that are taxable, and that are priced by weight.
+	Taxable   int `json:"taxable"`
+	PerWeight int `json:"per_weight"`
+
+	// ErrorCodes counts the failed lines by kind of failure.
+	ErrorCodes map[Code]int `json:"error_codes"`
+
+	// Units counts the records by pricing unit of measure.
+	Units map[product.UnitOfMeasure]int `json:"units"`
+
+	// Normalized counts the lines each normalization was applied to.
+	Normalized map[Normalization]int `json:"normalized"`
+
+	// Bytes is the number of bytes read from the input.
+	Bytes int64 `json:"bytes"`
+
+	// Elapsed is the duration of the run.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+func newStats() Stats {
+	return Stats{
+		ErrorCodes: make(map[Code]int),
+		Units:      make(map[product.UnitOfMeasure]int),
+		Normalized: make(map[Normalization]int),
+	}
+}
+
+// add counts a result.
+func (s *Stats) add(r Result) {
+	s.Rows++
+	for _, n := range r.Normalized {
+		s.Normalized[n]++
+	}
+
+	switch {
+	case r.blank:
+		s.Blank++
+	case r.Err != nil:
+		s.Errors++
+		s.ErrorCodes[CodeOf(r.Err)]++
+	default:
+		s.Records++
+		s.Units[r.Record.Unit]++
+
+		if r.Record.IsSplitPrice() {
+			s.SplitPrice++
+		} else {
+			s.SingularPrice++
+		}
+
+		if r.Record.IsSplitPromoPrice() {
+			s.SplitPromoPrice++
+		} else if !r.Record.PromoPrice.IsZero() {
+			s.SingularPromoPrice++
+		}
+
+		if r.Record.Flags.Taxable() {
+			s.Taxable++
+		}
+		if r.Record.Unit == product.UnitPound {
+			s.PerWeight++
+		}
+	}
+}
+
+// copy returns a copy of the stats that doesn't share maps with the original.
+func (s Stats) copy() Stats {
+	c := s
+	c.ErrorCodes = make(map[Code]int, len(s.ErrorCodes))
+	for k, v := range s.ErrorCodes {
+		c.ErrorCodes[k] = v
+	}
+	c.Units = make(map[product.UnitOfMeasure]int, len(s.Units))
+	for k, v := range s.Units {
+		c.Units[k] = v
+	}
+	c.Normalized = make(map[Normalization]int, len(s.Normalized))
+	for k, v := range s.Normalized {
+		c.Normalized[k] = v
+	}
+	return c
+}
+
+// Merge returns the combined stats of two runs, such as the runs over each file of an archive.
+func (s Stats) Merge(o Stats) Stats {
+	m := s.copy()
+	m.Rows += o.Rows
+	m.Records += o.Records
+	m.Errors += o.Errors
+	m.Blank += o.Blank
+	m.SingularPrice += o.SingularPrice
+	m.SplitPrice += o.SplitPrice
+	m.SingularPromoPrice += o.SingularPromoPrice
+	m.SplitPromoPrice += o.SplitPromoPrice
+	m.Taxable += o.Taxable
+	m.PerWeight += o.PerWeight
+	m.Bytes += o.Bytes
+	m.Elapsed += o.Elapsed
+	for k, v := range o.ErrorCodes {
+		m.ErrorCodes[k] += v
+	}
+	for k, v := range o.Units {
+		m.Units[k] += v
+	}
+	for k, v := range o.Normalized {
+		m.Normalized[k] += v
+	}
+	return m
+}
+
+// countingReader counts the bytes read through it. The count may be read while reads are in progress.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(&c.n, int64(n))
+	return n, err
+}
+
+func (c *countingReader) count() int64 {
+	return atomic.LoadInt64(&c.n)
+}
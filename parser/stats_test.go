@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type statsTestSuite struct {
+	suite.Suite
+	converter Converter
+}
+
+func Test_Stats(t *testing.T) {
+	s := new(statsTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *statsTestSuite) SetupSuite() {
+	s.converter, _ = product.NewConverter(NumberFieldLength, CurrencyFieldLength, FlagsFieldLength)
+}
+
+func (s *statsTestSuite) Test_Stats_CountsOutcomes() {
+	t := s.T()
+
+	input := "80000001 Kimchi-flavored white rice                                  00000567 00000000 00000000 00000000 00000000 00000000 NNNNNNNNN      18oz\n" +
+		"14963801 Generic Soda 12-pack                                        00000000 00000549 00001300 00000000 00000002 00000000 NNNNYNNNN   12x12oz\n" +
+		"\n" +
+		"40123401 Marlboro Cigarettes                                         00001000 00000549 00000000 00000000 00000000 00000000 YNNNNNNNN          \n" +
+		"40123401 Marlboro Cigare\n" +
+		"14963801 Generic Soda 12-pack                                        00000549 00000000 00000000 00001000 00000000 00000002 NNNNYNNNN   12x12oz\n" +
+		"50133333 Fuji Apples (Organic)                                       00000349 00000000 00000000 00000000 00000000 00000000 NNYNNNNNX        lb\n" +
+		"50133333 Fuji Apples (Organic)                                       00000349 00000000 00000000 00000000 00000000 00000000 NNYNNNNNN        lb\n" +
+		"   \n"
+	p, _ := New(strings.NewReader(input), s.converter)
+
+	count := 0
+	for r := range p.Results(context.Background()) {
+		require.NotEqual(t, 3, r.Line)
+		count++
+	}
+	require.NoError(t, p.Err())
+	require.Equal(t, 7, count)
+
+	stats := p.Stats()
+	require.Equal(t, 9, stats.Rows)
+	require.Equal(t, 5, stats.Records)
+	require.Equal(t, 2, stats.Errors)
+	require.Equal(t, 2, stats.Blank)
+	require.Equal(t, 4, stats.SingularPrice)
+	require.Equal(t, 1, stats.SplitPrice)
+	require.Equal(t, 2, stats.SingularPromoPrice)
+	require.Equal(t, 1, stats.SplitPromoPrice)
+	require.Equal(t, 2, stats.Taxable)
+	require.Equal(t, 1, stats.PerWeight)
+	require.Equal(t, map[Code]int{CodeBadLength: 1, CodeBadFormat: 1}, stats.ErrorCodes)
+	require.Equal(t, map[product.UnitOfMeasure]int{product.UnitEach: 4, product.UnitPound: 1}, stats.Units)
+	require.Equal(t, int64(len(input)), stats.Bytes)
+	require.True(t, stats.Elapsed > 0)
+}
+
+func (s *statsTestSuite) Test_Stats_ReturnsCopy() {
+	t := s.T()
+
+	p, _ := New(strings.NewReader("40123401 Marlboro Cigare\n"), s.converter)
+	for range p.Results(context.Background()) {
+	}
+
+	stats := p.Stats()
+	stats.ErrorCodes[CodeBadLength] = 100
+	require.Equal(t, 1, p.Stats().ErrorCodes[CodeBadLength])
+}
+
+func (s *statsTestSuite) Test_Merge_AddsCounts() {
+	t := s.T()
+
+	a := Stats{Rows: 3, Records: 2, Errors: 1, Bytes: 10, ErrorCodes: map[Code]int{CodeBadLength: 1}, Units: map[product.UnitOfMeasure]int{product.UnitEach: 2}}
+	b := Stats{Rows: 2, Records: 2, Bytes: 5, Units: map[product.UnitOfMeasure]int{product.UnitEach: 1, product.UnitPound: 1}, Normalized: map[Normalization]int{NormalizationPadShort: 2}}
+
+	m := a.Merge(b)
+	require.Equal(t, 5, m.Rows)
+	require.Equal(t, 4, m.Records)
+	require.Equal(t, 1, m.Errors)
+	require.Equal(t, int64(15), m.Bytes)
+	require.Equal(t, map[Code]int{CodeBadLength: 1}, m.ErrorCodes)
+	require.Equal(t, map[product.UnitOfMeasure]int{product.UnitEach: 3, product.UnitPound: 1}, m.Units)
+	require.Equal(t, map[Normalization]int{NormalizationPadShort: 2}, m.Normalized)
+	require.Equal(t, 2, a.Units[product.UnitEach])
+}
@@ -1,6 +1,8 @@
 package product
 
 import (
+	"bytes"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -13,6 +15,7 @@ type Converter struct {
 	numberLength   int
 	currencyLength int
 	flagsLength    int
+	flagPositions  []Flags
 }
 
 var (
@@ -32,11 +35,51 @@ func NewConverter(numFieldLength, currencyFieldLength, flagFieldLength int) (*Co
 		return nil, errors.WithStack(ErrBadParameter)
 	}
 
-	return &Converter{
+	c := &Converter{
 		numberLength:   numFieldLength,
 		currencyLength: currencyFieldLength,
 		flagsLength:    flagFieldLength,
-	}, nil
+	}
+
+	// Fields shorter than the default positions only use the leading positions.
+	names := DefaultFlagPositions
+	if len(names) > flagFieldLength {
+		names = names[:flagFieldLength]
+	}
+	if err := c.MapFlags(names...); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// MapFlags sets the names of the flags at each position of a flags field, replacing DefaultFlagPositions.
+// An empty name ignores that position, as do positions past the end of names.
+func (c *Converter) MapFlags(names ...string) error {
+	if len(names) > c.flagsLength {
+		return errors.Wrapf(ErrBadParameter, "%d flag names for a %d character flags field", len(names), c.flagsLength)
+	}
+
+	positions := make([]Flags, len(names))
+	var seen Flags
+
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		flag, err := ParseFlag(name)
+		if err != nil {
+			return err
+		}
+		if seen.Has(flag) {
+			return errors.Wrapf(ErrBadParameter, "flag %q is mapped more than once", name)
+		}
+		seen |= flag
+		positions[i] = flag
+	}
+
+	c.flagPositions = positions
+	return nil
 }
 
 // ToNumber converts text to an integer.
@@ -81,15 +124,59 @@ func (c *Converter) ToFlags(text []byte) (Flags, error) {
 		if b != 'Y' && b != 'N' {
 			return 0, errors.WithStack(ErrBadFormat)
 		}
-		if b == 'Y' {
-			switch i {
-			case 2:
-				flags |= FlagPerWeight
-			case 4:
-				flags |= FlagTaxable
-			}
+		if b == 'Y' && i < len(c.flagPositions) {
+			flags |= c.flagPositions[i]
 		}
 	}
 
 	return flags, nil
 }
+
+// FromNumber converts an integer to zero-padded text. It is the reverse of ToNumber.
+func (c *Converter) FromNumber(num int) ([]byte, error) {
+	return zeroPad(int64(num), c.numberLength)
+}
+
+// FromCurrency converts a decimal value to zero-padded text, in cents. It is the reverse of ToCurrency.
+func (c *Converter) FromCurrency(value decimal.Decimal) ([]byte, error) {
+	cents := value.Shift(2)
+	if !cents.Equal(cents.Truncate(0)) {
+		return nil, errors.WithStack(ErrBadFormat)
+	}
+	return zeroPad(cents.IntPart(), c.currencyLength)
+}
+
+// FromFlags converts a set of flags to Y/N text. It is the reverse of ToFlags.
+func (c *Converter) FromFlags(flags Flags) ([]byte, error) {
+	text := bytes.Repeat([]byte{'N'}, c.flagsLength)
+
+	var written Flags
+	for i, flag := range c.flagPositions {
+		if flag != FlagNone && flags.Has(flag) {
+			text[i] = 'Y'
+			written |= flag
+		}
+	}
+
+	// A flag without a position can't be written.
+	if written != flags {
+		return nil, errors.Wrapf(ErrBadParameter, "no position for flags %v", flags&^written)
+	}
+
+	return text, nil
+}
+
+// zeroPad formats num as text of exactly length bytes, padded with leading zeros.
+func zeroPad(num int64, length int) ([]byte, error) {
+	var text string
+	if num < 0 {
+		text = fmt.Sprintf("-%0*d", length-1, -num)
+	} else {
+		text = fmt.Sprintf("%0*d", length, num)
+	}
+
+	if len(text) != length {
+		return nil, errors.WithStack(ErrBadFieldLength)
+	}
+	return []byte(text), nil
+}
@@ -201,3 +201,147 @@ func (s *converterTestSuite) Test_ToFlags_PerWeightTaxable_ReturnsBoth() {
 	require.NoError(s.T(), err)
 	require.Equal(s.T(), FlagPerWeight|FlagTaxable, flags)
 }
+
+func (s *converterTestSuite) Test_ToFlags_AgeRestricted_ReturnsFlagAgeRestricted() {
+	flags, err := s.convert.ToFlags([]byte("YNNNNNNNN"))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), FlagAgeRestricted, flags)
+}
+
+func (s *converterTestSuite) Test_ToFlags_AllYes_ReturnsEveryFlag() {
+	flags, err := s.convert.ToFlags([]byte("YYYYYYYYY"))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []string{"per_weight", "taxable", "age_restricted", "food_stamp", "organic", "wic", "deposit", "local", "discontinued"}, flags.Names())
+}
+
+func (s *converterTestSuite) Test_ToFlags_EachPosition_ReturnsDefaultFlag() {
+	for i, name := range DefaultFlagPositions {
+		text := []byte("NNNNNNNNN")
+		text[i] = 'Y'
+
+		flags, err := s.convert.ToFlags(text)
+		require.NoError(s.T(), err)
+
+		expected, _ := ParseFlag(name)
+		require.Equal(s.T(), expected, flags, "position %d", i)
+	}
+}
+
+func (s *converterTestSuite) Test_MapFlags_CustomPositions_ReturnsMappedFlags() {
+	c, _ := NewConverter(8, 8, 9)
+	require.NoError(s.T(), c.MapFlags("organic", "", "per_weight"))
+
+	flags, err := c.ToFlags([]byte("YYYYYYYYY"))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), FlagOrganic|FlagPerWeight, flags)
+
+	text, err := c.FromFlags(FlagOrganic)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "YNNNNNNNN", string(text))
+}
+
+func (s *converterTestSuite) Test_MapFlags_UnknownName_ReturnsError() {
+	c, _ := NewConverter(8, 8, 9)
+	err := c.MapFlags("perishable")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *converterTestSuite) Test_MapFlags_DuplicateName_ReturnsError() {
+	c, _ := NewConverter(8, 8, 9)
+	err := c.MapFlags("taxable", "taxable")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *converterTestSuite) Test_MapFlags_TooManyNames_ReturnsError() {
+	c, _ := NewConverter(8, 8, 2)
+	err := c.MapFlags("taxable", "organic", "wic")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *converterTestSuite) Test_FromNumber_ReturnsZeroPaddedText() {
+	text, err := s.convert.FromNumber(1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "00000001", string(text))
+}
+
+func (s *converterTestSuite) Test_FromNumber_Negative_ReturnsText() {
+	text, err := s.convert.FromNumber(-1234567)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "-1234567", string(text))
+}
+
+func (s *converterTestSuite) Test_FromNumber_TooLong_ReturnsError() {
+	_, err := s.convert.FromNumber(123456789)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadFieldLength, errors.Cause(err))
+}
+
+func (s *converterTestSuite) Test_FromCurrency_ReturnsCents() {
+	text, err := s.convert.FromCurrency(decimal.New(1999, -2))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "00001999", string(text))
+}
+
+func (s *converterTestSuite) Test_FromCurrency_WholeDollars_ReturnsCents() {
+	text, err := s.convert.FromCurrency(decimal.New(99, 0))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "00009900", string(text))
+}
+
+func (s *converterTestSuite) Test_FromCurrency_Negative_ReturnsCents() {
+	text, err := s.convert.FromCurrency(decimal.New(-1999, -2))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "-0001999", string(text))
+}
+
+func (s *converterTestSuite) Test_FromCurrency_FractionalCents_ReturnsError() {
+	_, err := s.convert.FromCurrency(decimal.New(65, -3))
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadFormat, errors.Cause(err))
+}
+
+func (s *converterTestSuite) Test_FromCurrency_RoundTrips() {
+	for _, text := range []string{"00000000", "00000001", "00001300", "12345678", "-0001999"} {
+		value, err := s.convert.ToCurrency([]byte(text))
+		require.NoError(s.T(), err)
+
+		result, err := s.convert.FromCurrency(value)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), text, string(result))
+	}
+}
+
+func (s *converterTestSuite) Test_FromFlags_None_ReturnsAllNo() {
+	text, err := s.convert.FromFlags(FlagNone)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "NNNNNNNNN", string(text))
+}
+
+func (s *converterTestSuite) Test_FromFlags_PerWeightTaxable_ReturnsBoth() {
+	text, err := s.convert.FromFlags(FlagPerWeight | FlagTaxable)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "NNYNYNNNN", string(text))
+}
+
+func (s *converterTestSuite) Test_FromFlags_UnmappedFlag_ReturnsError() {
+	c, _ := NewConverter(8, 8, 9)
+	require.NoError(s.T(), c.MapFlags("taxable"))
+
+	_, err := c.FromFlags(FlagTaxable | FlagOrganic)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *converterTestSuite) Test_FromFlags_RoundTrips() {
+	for _, text := range []string{"NNNNNNNNN", "YNNNNNNNN", "NNYNYNNNN", "YYYYYYYYY", "NYNYNYNYN"} {
+		flags, err := s.convert.ToFlags([]byte(text))
+		require.NoError(s.T(), err)
+
+		result, err := s.convert.FromFlags(flags)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), text, string(result))
+	}
+}
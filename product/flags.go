@@ -1,5 +1,11 @@
 package product
 
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
 // Flags specify boolean product characteristics.
 type Flags uint
 
@@ -12,8 +18,70 @@ const (
 
 	// FlagTaxable indicates a product that is taxable.
 	FlagTaxable Flags = 2
+
+	// FlagAgeRestricted indicates a product that requires age verification, such as tobacco or alcohol.
+	FlagAgeRestricted Flags = 4
+
+	// FlagFoodStamp indicates a product that is eligible for food stamp (SNAP) purchase.
+	FlagFoodStamp Flags = 8
+
+	// FlagOrganic indicates a product that is certified organic.
+	FlagOrganic Flags = 16
+
+	// FlagWIC indicates a product that is eligible for WIC purchase.
+	FlagWIC Flags = 32
+
+	// FlagDeposit indicates a product that carries a container deposit.
+	FlagDeposit Flags = 64
+
+	// FlagLocal indicates a locally sourced product.
+	FlagLocal Flags = 128
+
+	// FlagDiscontinued indicates a product that is discontinued.
+	FlagDiscontinued Flags = 256
 )
 
+var (
+	// flagNames are the names of the flags, in bit order.
+	flagNames = []struct {
+		flag Flags
+		name string
+	}{
+		{FlagPerWeight, "per_weight"},
+		{FlagTaxable, "taxable"},
+		{FlagAgeRestricted, "age_restricted"},
+		{FlagFoodStamp, "food_stamp"},
+		{FlagOrganic, "organic"},
+		{FlagWIC, "wic"},
+		{FlagDeposit, "deposit"},
+		{FlagLocal, "local"},
+		{FlagDiscontinued, "discontinued"},
+	}
+
+	// DefaultFlagPositions are the names of the flags at each position of a flags field.
+	DefaultFlagPositions = []string{
+		"age_restricted",
+		"food_stamp",
+		"per_weight",
+		"organic",
+		"taxable",
+		"wic",
+		"deposit",
+		"local",
+		"discontinued",
+	}
+)
+
+// ParseFlag returns the flag with the given name.
+func ParseFlag(name string) (Flags, error) {
+	for _, f := range flagNames {
+		if f.name == name {
+			return f.flag, nil
+		}
+	}
+	return FlagNone, errors.Wrapf(ErrBadParameter, "unknown flag %q", name)
+}
+
 // PerWeight returns true if the flags include FlagPerWeight
 func (f Flags) PerWeight() bool {
 	return f&FlagPerWeight == FlagPerWeight
@@ -23,3 +91,38 @@ func (f Flags) PerWeight() bool {
 func (f Flags) Taxable() bool {
 	return f&FlagTaxable == FlagTaxable
 }
+
+// AgeRestricted returns true if the flags include FlagAgeRestricted
+func (f Flags) AgeRestricted() bool {
+	return f&FlagAgeRestricted == FlagAgeRestricted
+}
+
+// FoodStamp returns true if the flags include FlagFoodStamp
+func (f Flags) FoodStamp() bool {
+	return f&FlagFoodStamp == FlagFoodStamp
+}
+
+// Organic returns true if the flags include FlagOrganic
+func (f Flags) Organic() bool {
+	return f&FlagOrganic == FlagOrganic
+}
+
+// Has returns true if the flags include all of the given flags.
+func (f Flags) Has(flags Flags) bool {
+	return f&flags == flags
+}
+
+// Names returns the names of the flags that are set, in bit order.
+func (f Flags) Names() []string {
+	names := []string{}
+	for _, n := range flagNames {
+		if f.Has(n.flag) {
+			names = append(names, n.name)
+		}
+	}
+	return names
+}
+
+func (f Flags) String() string {
+	return strings.Join(f.Names(), ",")
+}
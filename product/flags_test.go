@@ -1,8 +1,10 @@
 package product
 
 import (
-	"github.com/stretchr/testify/require"
 	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_DefaultValue_FlagNone(t *testing.T) {
@@ -39,3 +41,29 @@ func Test_FlagPerWeightAndTaxable_IsTaxable(t *testing.T) {
 	f := FlagPerWeight | FlagTaxable
 	require.True(t, f.Taxable())
 }
+
+func Test_FlagAgeRestricted_IsAgeRestricted(t *testing.T) {
+	f := FlagAgeRestricted | FlagTaxable
+	require.True(t, f.AgeRestricted())
+	require.False(t, f.FoodStamp())
+}
+
+func Test_Names_ReturnsNamesInBitOrder(t *testing.T) {
+	f := FlagDiscontinued | FlagFoodStamp | FlagPerWeight
+	require.Equal(t, []string{"per_weight", "food_stamp", "discontinued"}, f.Names())
+	require.Equal(t, "per_weight,food_stamp,discontinued", f.String())
+}
+
+func Test_ParseFlag_DefaultPositions_AreKnown(t *testing.T) {
+	for _, name := range DefaultFlagPositions {
+		f, err := ParseFlag(name)
+		require.NoError(t, err)
+		require.Equal(t, []string{name}, f.Names())
+	}
+}
+
+func Test_ParseFlag_UnknownName_ReturnsError(t *testing.T) {
+	_, err := ParseFlag("perishable")
+	require.Error(t, err)
+	require.Equal(t, ErrBadParameter, errors.Cause(err))
+}
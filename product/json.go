@@ -0,0 +1,107 @@
+package product
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// MarshalJSON writes the flags as a list of their names, such as ["per_weight","taxable"].
+func (f Flags) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Names())
+}
+
+// UnmarshalJSON reads flags written by MarshalJSON.
+func (f *Flags) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return errors.WithStack(err)
+	}
+
+	flags := FlagNone
+	for _, name := range names {
+		flag, err := ParseFlag(name)
+		if err != nil {
+			return err
+		}
+		flags |= flag
+	}
+
+	*f = flags
+	return nil
+}
+
+// recordFields is a Record without its JSON methods.
+type recordFields Record
+
+// recordJSON is the JSON form of a Record, which leaves out the quantity, unit prices and split prices it doesn't have.
+// Decimals are written as strings, so they are exact.
+type recordJSON struct {
+	recordFields
+	Quantity           *Quantity        `json:"quantity,omitempty"`
+	UnitPrice          *decimal.Decimal `json:"unit_price,omitempty"`
+	PromoUnitPrice     *decimal.Decimal `json:"promo_unit_price,omitempty"`
+	UnitPriceBasis     *Basis           `json:"unit_price_basis,omitempty"`
+	SplitPrice         *decimal.Decimal `json:"split_price,omitempty"`
+	SplitQuantity      int              `json:"split_quantity,omitempty"`
+	PromoSplitPrice    *decimal.Decimal `json:"promo_split_price,omitempty"`
+	PromoSplitQuantity int              `json:"promo_split_quantity,omitempty"`
+}
+
+// MarshalJSON writes the record as a JSON object.
+func (r Record) MarshalJSON() ([]byte, error) {
+	out := recordJSON{recordFields: recordFields(r)}
+
+	if !r.Quantity.IsZero() {
+		out.Quantity = &r.Quantity
+	}
+	if !r.UnitPriceBasis.IsZero() {
+		out.UnitPrice = &r.UnitPrice
+		out.PromoUnitPrice = &r.PromoUnitPrice
+		out.UnitPriceBasis = &r.UnitPriceBasis
+	}
+	if r.IsSplitPrice() {
+		out.SplitPrice = &r.SplitPrice
+		out.SplitQuantity = r.SplitQuantity
+	}
+	if r.IsSplitPromoPrice() {
+		out.PromoSplitPrice = &r.PromoSplitPrice
+		out.PromoSplitQuantity = r.PromoSplitQuantity
+	}
+
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON reads a record written by MarshalJSON.
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var in recordJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return errors.WithStack(err)
+	}
+
+	record := Record(in.recordFields)
+	if in.Quantity != nil {
+		record.Quantity = *in.Quantity
+	}
+	if in.UnitPriceBasis != nil {
+		record.UnitPriceBasis = *in.UnitPriceBasis
+	}
+	if in.UnitPrice != nil {
+		record.UnitPrice = *in.UnitPrice
+	}
+	if in.PromoUnitPrice != nil {
+		record.PromoUnitPrice = *in.PromoUnitPrice
+	}
+	if in.SplitPrice != nil {
+		record.SplitPrice = *in.SplitPrice
+	}
+	if in.PromoSplitPrice != nil {
+		record.PromoSplitPrice = *in.PromoSplitPrice
+	}
+	record.SplitQuantity = in.SplitQuantity
+	record.PromoSplitQuantity = in.PromoSplitQuantity
+
+	*r = record
+	return nil
+}
@@ -0,0 +1,82 @@
+package product
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Flags_MarshalJSON_WritesNames(t *testing.T) {
+	data, err := json.Marshal(FlagTaxable | FlagAgeRestricted)
+	require.NoError(t, err)
+	require.Equal(t, `["taxable","age_restricted"]`, string(data))
+
+	data, err = json.Marshal(FlagNone)
+	require.NoError(t, err)
+	require.Equal(t, `[]`, string(data))
+}
+
+func Test_Flags_UnmarshalJSON_ReadsNames(t *testing.T) {
+	var f Flags
+	require.NoError(t, json.Unmarshal([]byte(`["organic","wic"]`), &f))
+	require.Equal(t, FlagOrganic|FlagWIC, f)
+}
+
+func Test_Flags_UnmarshalJSON_UnknownName_ReturnsError(t *testing.T) {
+	var f Flags
+	err := json.Unmarshal([]byte(`["perishable"]`), &f)
+	require.Error(t, err)
+	require.Equal(t, ErrBadParameter, errors.Cause(err))
+}
+
+func Test_Record_MarshalJSON_WritesDecimalsAsStrings(t *testing.T) {
+	r := Record{ID: 1, Price: decimal.New(65, -1), TaxRate: decimal.New(7775, -5)}
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+	require.Equal(t, "6.5", fields["price"])
+	require.Equal(t, "0.07775", fields["tax_rate"])
+	require.NotContains(t, fields, "quantity")
+	require.NotContains(t, fields, "unit_price")
+	require.NotContains(t, fields, "split_price")
+}
+
+func Test_Record_JSON_RoundTrips(t *testing.T) {
+	q, _ := ParseSize("12x12oz")
+	r := Record{
+		ID:                 14963801,
+		Description:        "Generic Soda 12-pack",
+		DisplayPrice:       "2/$13.00",
+		Price:              decimal.New(65, -1),
+		PromoDisplayPrice:  "3/$15.00",
+		PromoPrice:         decimal.New(5, 0),
+		Unit:               UnitEach,
+		Size:               "12x12oz",
+		TaxRate:            decimal.New(7775, -5),
+		Flags:              FlagTaxable | FlagDeposit,
+		Quantity:           q,
+		SplitPrice:         decimal.New(13, 0),
+		SplitQuantity:      2,
+		PromoSplitPrice:    decimal.New(15, 0),
+		PromoSplitQuantity: 3,
+	}
+	require.NoError(t, r.SetUnitPrices(UnitPricingUS))
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+
+	var result Record
+	require.NoError(t, json.Unmarshal(data, &result))
+
+	again, err := json.Marshal(result)
+	require.NoError(t, err)
+	require.JSONEq(t, string(data), string(again))
+	require.Equal(t, r.Flags, result.Flags)
+	require.Equal(t, r.Quantity.Pack, result.Quantity.Pack)
+	require.True(t, r.UnitPrice.Equal(result.UnitPrice))
+}
@@ -8,15 +8,74 @@ import (
 
 // Record is the parsed Product
 type Record struct {
-	ID                int
-	Description       string
-	DisplayPrice      string
-	Price             decimal.Decimal
-	PromoDisplayPrice string
-	PromoPrice        decimal.Decimal
-	Unit              UnitOfMeasure
-	Size              string
-	TaxRate           decimal.Decimal
+	ID                int             `json:"id"`
+	Description       string          `json:"description"`
+	DisplayPrice      string          `json:"display_price"`
+	Price             decimal.Decimal `json:"price"`
+	PromoDisplayPrice string          `json:"promo_display_price"`
+	PromoPrice        decimal.Decimal `json:"promo_price"`
+	Unit              UnitOfMeasure   `json:"unit"`
+	Size              string          `json:"size"`
+	TaxRate           decimal.Decimal `json:"tax_rate"`
+	Flags             Flags           `json:"flags"`
+
+	// Quantity is Size parsed into a pack count, amount and unit. It is zero if the size is empty or not recognized.
+	Quantity Quantity `json:"quantity"`
+
+	// UnitPrice and PromoUnitPrice are the prices of a UnitPriceBasis amount of the product, such as 1 oz, calculated
+	// from Price, PromoPrice and Quantity. They are zero, with a zero basis, if the product has no quantity.
+	UnitPrice      decimal.Decimal `json:"unit_price"`
+	PromoUnitPrice decimal.Decimal `json:"promo_unit_price"`
+	UnitPriceBasis Basis           `json:"unit_price_basis"`
+
+	// SplitPrice and SplitQuantity are the multi-buy deal Price was calculated from, such as 2 for $13.00.
+	// SplitQuantity is zero if the product has a singular price.
+	SplitPrice    decimal.Decimal `json:"split_price"`
+	SplitQuantity int             `json:"split_quantity"`
+
+	// PromoSplitPrice and PromoSplitQuantity are the multi-buy deal PromoPrice was calculated from.
+	// PromoSplitQuantity is zero if the product has a singular promotional price, or none.
+	PromoSplitPrice    decimal.Decimal `json:"promo_split_price"`
+	PromoSplitQuantity int             `json:"promo_split_quantity"`
+}
+
+// IsSplitPrice returns true if the price is from a multi-buy deal.
+func (r Record) IsSplitPrice() bool {
+	return r.SplitQuantity != 0
+}
+
+// IsSplitPromoPrice returns true if the promotional price is from a multi-buy deal.
+func (r Record) IsSplitPromoPrice() bool {
+	return r.PromoSplitQuantity != 0
+}
+
+// SetUnitPrices calculates the unit prices of the record's price and promotional price with the given rules.
+func (r *Record) SetUnitPrices(u UnitPricing) error {
+	price, basis, err := u.UnitPrice(r.Price, r.Quantity)
+	if err != nil {
+		return err
+	}
+
+	promoPrice := decimal.Zero
+	if !r.PromoPrice.IsZero() {
+		promoPrice, _, err = u.UnitPrice(r.PromoPrice, r.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.UnitPrice = price
+	r.PromoUnitPrice = promoPrice
+	r.UnitPriceBasis = basis
+	return nil
+}
+
+// FormatPrice formats a price for display, as "$6.50", or as "2/$13.00" for a split price of quantity items.
+func FormatPrice(price, splitPrice decimal.Decimal, quantity int) string {
+	if quantity != 0 {
+		return fmt.Sprintf("%d/$%s", quantity, splitPrice.StringFixed(2))
+	}
+	return "$" + price.StringFixed(2)
 }
 
 func (r Record) String() string {
@@ -0,0 +1,50 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FormatPrice_Singular_FormatsDollars(t *testing.T) {
+	require.Equal(t, "$5.67", FormatPrice(decimal.New(567, -2), decimal.Zero, 0))
+}
+
+func Test_FormatPrice_Zero_FormatsDollars(t *testing.T) {
+	require.Equal(t, "$0.00", FormatPrice(decimal.Zero, decimal.Zero, 0))
+}
+
+func Test_FormatPrice_Split_FormatsQuantityAndSplitPrice(t *testing.T) {
+	require.Equal(t, "2/$13.00", FormatPrice(decimal.New(65, -1), decimal.New(1300, -2), 2))
+}
+
+func Test_Record_NoSplitQuantity_IsNotSplitPrice(t *testing.T) {
+	r := Record{Price: decimal.New(549, -2)}
+	require.False(t, r.IsSplitPrice())
+	require.False(t, r.IsSplitPromoPrice())
+}
+
+func Test_Record_SplitQuantity_IsSplitPrice(t *testing.T) {
+	r := Record{SplitQuantity: 2, PromoSplitQuantity: 3}
+	require.True(t, r.IsSplitPrice())
+	require.True(t, r.IsSplitPromoPrice())
+}
+
+func Test_Record_SetUnitPrices_SetsRegularAndPromo(t *testing.T) {
+	q, _ := ParseSize("12x12oz")
+	r := Record{Price: decimal.New(65, -1), PromoPrice: decimal.New(549, -2), Quantity: q}
+
+	require.NoError(t, r.SetUnitPrices(UnitPricingUS))
+	require.Equal(t, "0.045", r.UnitPrice.String())
+	require.Equal(t, "0.038", r.PromoUnitPrice.String())
+	require.Equal(t, UnitOunce, r.UnitPriceBasis.Unit)
+}
+
+func Test_Record_SetUnitPrices_NoPromo_HasZeroPromoUnitPrice(t *testing.T) {
+	q, _ := ParseSize("18oz")
+	r := Record{Price: decimal.New(567, -2), Quantity: q}
+
+	require.NoError(t, r.SetUnitPrices(UnitPricingUS))
+	require.True(t, r.PromoUnitPrice.IsZero())
+}
@@ -0,0 +1,92 @@
+package product
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// sizePattern matches a size such as "18oz", "12x12oz", "2 x 1.5 L" or "lb": an optional pack count,
+// an optional amount, and a unit.
+var sizePattern = regexp.MustCompile(`^(?:(\d+)\s*[xX]\s*)?(\d*\.?\d+)?\s*([^\d\s].*)$`)
+
+// Quantity is the structured size of a product: a pack of items, each of an amount of a unit of measure.
+type Quantity struct {
+	// Pack is the number of items in a multi-pack, or 1 for a single item.
+	Pack   int             `json:"pack"`
+	Amount decimal.Decimal `json:"amount"`
+	Unit   UnitOfMeasure   `json:"unit"`
+}
+
+// ParseSize parses a product size, such as "18oz", "12x12oz" or "lb". A size without an amount is
+// one of its unit. An empty size returns the zero Quantity.
+func ParseSize(text string) (Quantity, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Quantity{}, nil
+	}
+
+	m := sizePattern.FindStringSubmatch(text)
+	if m == nil {
+		return Quantity{}, errors.Wrapf(ErrBadFormat, "unrecognized size %q", text)
+	}
+
+	q := Quantity{Pack: 1, Amount: decimal.New(1, 0)}
+
+	if m[1] != "" {
+		pack, err := strconv.Atoi(m[1])
+		if err != nil || pack < 1 {
+			return Quantity{}, errors.Wrapf(ErrBadFormat, "bad pack count in size %q", text)
+		}
+		q.Pack = pack
+	}
+
+	if m[2] != "" {
+		amount, err := decimal.NewFromString(m[2])
+		if err != nil || !amount.IsPositive() {
+			return Quantity{}, errors.Wrapf(ErrBadFormat, "bad amount in size %q", text)
+		}
+		q.Amount = amount
+	}
+
+	unit, err := ParseUnit(m[3])
+	if err != nil {
+		return Quantity{}, err
+	}
+	q.Unit = unit
+
+	return q, nil
+}
+
+// IsZero returns true for the zero Quantity, the size of a product without one.
+func (q Quantity) IsZero() bool {
+	return q.Unit == ""
+}
+
+// Total returns the amount of the whole pack.
+func (q Quantity) Total() decimal.Decimal {
+	return q.Amount.Mul(decimal.New(int64(q.Pack), 0))
+}
+
+// Convert returns the quantity with each item's amount in a different unit of the same dimension.
+func (q Quantity) Convert(to UnitOfMeasure) (Quantity, error) {
+	amount, err := Convert(q.Amount, q.Unit, to)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return Quantity{Pack: q.Pack, Amount: amount, Unit: to}, nil
+}
+
+func (q Quantity) String() string {
+	if q.IsZero() {
+		return ""
+	}
+	if q.Pack > 1 {
+		return fmt.Sprintf("%dx%s%s", q.Pack, q.Amount, q.Unit.Symbol())
+	}
+	return fmt.Sprintf("%s%s", q.Amount, q.Unit.Symbol())
+}
@@ -0,0 +1,72 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseSize_Amount_ReturnsSingleItem(t *testing.T) {
+	q, err := ParseSize("18oz")
+	require.NoError(t, err)
+	require.Equal(t, 1, q.Pack)
+	require.Equal(t, "18", q.Amount.String())
+	require.Equal(t, UnitOunce, q.Unit)
+}
+
+func Test_ParseSize_Pack_ReturnsPackCount(t *testing.T) {
+	q, err := ParseSize("12x12oz")
+	require.NoError(t, err)
+	require.Equal(t, 12, q.Pack)
+	require.Equal(t, "12", q.Amount.String())
+	require.Equal(t, UnitOunce, q.Unit)
+	require.Equal(t, "144", q.Total().String())
+}
+
+func Test_ParseSize_UnitOnly_ReturnsOneUnit(t *testing.T) {
+	q, err := ParseSize("       lb")
+	require.NoError(t, err)
+	require.Equal(t, Quantity{Pack: 1, Amount: decimal.New(1, 0), Unit: UnitPound}, q)
+}
+
+func Test_ParseSize_SpacedDecimal_ReturnsQuantity(t *testing.T) {
+	q, err := ParseSize("6 x 1.5 L")
+	require.NoError(t, err)
+	require.Equal(t, 6, q.Pack)
+	require.Equal(t, "1.5", q.Amount.String())
+	require.Equal(t, UnitLiter, q.Unit)
+}
+
+func Test_ParseSize_FluidOunce_ReturnsQuantity(t *testing.T) {
+	q, err := ParseSize("64 fl oz")
+	require.NoError(t, err)
+	require.Equal(t, UnitFluidOunce, q.Unit)
+}
+
+func Test_ParseSize_Empty_ReturnsZero(t *testing.T) {
+	q, err := ParseSize("   ")
+	require.NoError(t, err)
+	require.True(t, q.IsZero())
+}
+
+func Test_ParseSize_Unrecognized_ReturnsError(t *testing.T) {
+	for _, text := range []string{"family size", "12x", "0x12oz", "x12oz"} {
+		_, err := ParseSize(text)
+		require.Error(t, err, text)
+		require.Equal(t, ErrBadFormat, errors.Cause(err), text)
+	}
+}
+
+func Test_Quantity_Convert_ConvertsEachItem(t *testing.T) {
+	q, _ := ParseSize("2x1lb")
+	oz, err := q.Convert(UnitOunce)
+	require.NoError(t, err)
+	require.Equal(t, "2x16oz", oz.String())
+}
+
+func Test_Quantity_String_FormatsSize(t *testing.T) {
+	q, _ := ParseSize("12 X 12 OZ")
+	require.Equal(t, "12x12oz", q.String())
+}
@@ -1,5 +1,12 @@
 package product
 
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
 type UnitOfMeasure string
 
 const (
@@ -8,4 +15,163 @@ const (
 
 	// UnitPound expresses a weight-based pricing unit of measure
 	UnitPound UnitOfMeasure = "Pound"
+
+	// UnitOunce is a weight of 28.349523125 grams.
+	UnitOunce UnitOfMeasure = "Ounce"
+
+	// UnitKilogram is a weight of 1000 grams.
+	UnitKilogram UnitOfMeasure = "Kilogram"
+
+	// UnitGram is a weight in grams.
+	UnitGram UnitOfMeasure = "Gram"
+
+	// UnitFluidOunce is a US fluid ounce, a volume of 29.5735295625 milliliters.
+	UnitFluidOunce UnitOfMeasure = "Fluid Ounce"
+
+	// UnitLiter is a volume of 1000 milliliters.
+	UnitLiter UnitOfMeasure = "Liter"
+
+	// UnitMilliliter is a volume in milliliters.
+	UnitMilliliter UnitOfMeasure = "Milliliter"
+
+	// UnitCount is a number of items, such as the sheets in a roll.
+	UnitCount UnitOfMeasure = "Count"
+)
+
+// Dimension is the kind of amount a unit of measure measures. Only units of the same dimension can be converted.
+type Dimension string
+
+const (
+	// DimensionNone is the dimension of an unknown unit.
+	DimensionNone Dimension = ""
+
+	// DimensionWeight is measured in grams.
+	DimensionWeight Dimension = "weight"
+
+	// DimensionVolume is measured in milliliters.
+	DimensionVolume Dimension = "volume"
+
+	// DimensionCount is measured in items.
+	DimensionCount Dimension = "count"
+)
+
+var (
+	// ErrIncompatibleUnits is the error returned when converting between units of different dimensions.
+	ErrIncompatibleUnits = errors.New("Incompatible units of measure")
+
+	// units is the catalog of units of measure, with the symbol used to write each one and
+	// its size in the base unit of its dimension.
+	units = []struct {
+		unit      UnitOfMeasure
+		symbol    string
+		dimension Dimension
+		base      decimal.Decimal
+	}{
+		{UnitEach, "ea", DimensionCount, decimal.New(1, 0)},
+		{UnitCount, "ct", DimensionCount, decimal.New(1, 0)},
+		{UnitPound, "lb", DimensionWeight, decimal.New(45359237, -5)},
+		{UnitOunce, "oz", DimensionWeight, decimal.New(28349523125, -9)},
+		{UnitKilogram, "kg", DimensionWeight, decimal.New(1000, 0)},
+		{UnitGram, "g", DimensionWeight, decimal.New(1, 0)},
+		{UnitFluidOunce, "fl oz", DimensionVolume, decimal.New(295735295625, -10)},
+		{UnitLiter, "L", DimensionVolume, decimal.New(1000, 0)},
+		{UnitMilliliter, "mL", DimensionVolume, decimal.New(1, 0)},
+	}
+
+	// unitAliases are the other ways sizes write units, in lower case without spaces or periods.
+	unitAliases = map[string]UnitOfMeasure{
+		"each":        UnitEach,
+		"count":       UnitCount,
+		"pk":          UnitCount,
+		"pack":        UnitCount,
+		"lbs":         UnitPound,
+		"pound":       UnitPound,
+		"pounds":      UnitPound,
+		"#":           UnitPound,
+		"ounce":       UnitOunce,
+		"ounces":      UnitOunce,
+		"kilo":        UnitKilogram,
+		"kilogram":    UnitKilogram,
+		"kilograms":   UnitKilogram,
+		"gr":          UnitGram,
+		"gram":        UnitGram,
+		"grams":       UnitGram,
+		"floz":        UnitFluidOunce,
+		"fluidounce":  UnitFluidOunce,
+		"fluidounces": UnitFluidOunce,
+		"l":           UnitLiter,
+		"ltr":         UnitLiter,
+		"liter":       UnitLiter,
+		"liters":      UnitLiter,
+		"litre":       UnitLiter,
+		"litres":      UnitLiter,
+		"ml":          UnitMilliliter,
+		"milliliter":  UnitMilliliter,
+		"milliliters": UnitMilliliter,
+	}
+
+	// unitSeparators strips the spaces and periods ParseUnit ignores. It is built once because a
+	// Replacer is costly to build and ParseUnit runs for every row.
+	unitSeparators = strings.NewReplacer(" ", "", ".", "")
 )
+
+// ParseUnit returns the unit of measure written as symbol, such as "oz", "fl oz" or "mL". Case, spaces and
+// periods are ignored, and common names and plurals are accepted.
+func ParseUnit(symbol string) (UnitOfMeasure, error) {
+	key := strings.ToLower(unitSeparators.Replace(symbol))
+
+	for _, u := range units {
+		if strings.ToLower(strings.Replace(u.symbol, " ", "", -1)) == key {
+			return u.unit, nil
+		}
+	}
+	if unit, ok := unitAliases[key]; ok {
+		return unit, nil
+	}
+
+	return "", errors.Wrapf(ErrBadFormat, "unknown unit of measure %q", symbol)
+}
+
+// Symbol returns the abbreviation of the unit, such as "oz", or "" for an unknown unit.
+func (u UnitOfMeasure) Symbol() string {
+	for _, c := range units {
+		if c.unit == u {
+			return c.symbol
+		}
+	}
+	return ""
+}
+
+// Dimension returns the kind of amount the unit measures.
+func (u UnitOfMeasure) Dimension() Dimension {
+	for _, c := range units {
+		if c.unit == u {
+			return c.dimension
+		}
+	}
+	return DimensionNone
+}
+
+// base returns the size of the unit in the base unit of its dimension.
+func (u UnitOfMeasure) base() (decimal.Decimal, bool) {
+	for _, c := range units {
+		if c.unit == u {
+			return c.base, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+// Convert returns an amount in from units as an amount in to units.
+func Convert(amount decimal.Decimal, from, to UnitOfMeasure) (decimal.Decimal, error) {
+	if from == to {
+		return amount, nil
+	}
+	if from.Dimension() == DimensionNone || from.Dimension() != to.Dimension() {
+		return decimal.Zero, errors.Wrapf(ErrIncompatibleUnits, "converting %s to %s", from, to)
+	}
+
+	fromBase, _ := from.base()
+	toBase, _ := to.base()
+	return amount.Mul(fromBase).Div(toBase), nil
+}
@@ -0,0 +1,73 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseUnit_Symbols_ReturnUnits(t *testing.T) {
+	for symbol, expected := range map[string]UnitOfMeasure{
+		"oz":    UnitOunce,
+		"fl oz": UnitFluidOunce,
+		"FL.OZ": UnitFluidOunce,
+		"lb":    UnitPound,
+		"lbs":   UnitPound,
+		"kg":    UnitKilogram,
+		"g":     UnitGram,
+		"L":     UnitLiter,
+		"mL":    UnitMilliliter,
+		"ct":    UnitCount,
+		"ea":    UnitEach,
+	} {
+		unit, err := ParseUnit(symbol)
+		require.NoError(t, err, symbol)
+		require.Equal(t, expected, unit, symbol)
+	}
+}
+
+func Test_ParseUnit_Unknown_ReturnsError(t *testing.T) {
+	_, err := ParseUnit("bushel")
+	require.Error(t, err)
+	require.Equal(t, ErrBadFormat, errors.Cause(err))
+}
+
+func Test_UnitOfMeasure_Symbol_ParsesToSameUnit(t *testing.T) {
+	for _, u := range units {
+		unit, err := ParseUnit(u.unit.Symbol())
+		require.NoError(t, err)
+		require.Equal(t, u.unit, unit)
+	}
+}
+
+func Test_Convert_PoundToOunce_ReturnsSixteen(t *testing.T) {
+	amount, err := Convert(decimal.New(1, 0), UnitPound, UnitOunce)
+	require.NoError(t, err)
+	require.Equal(t, "16", amount.String())
+}
+
+func Test_Convert_KilogramToGram_ReturnsExactAmount(t *testing.T) {
+	amount, err := Convert(decimal.New(15, -1), UnitKilogram, UnitGram)
+	require.NoError(t, err)
+	require.Equal(t, "1500", amount.String())
+}
+
+func Test_Convert_LiterToFluidOunce_ReturnsAmount(t *testing.T) {
+	amount, err := Convert(decimal.New(2, 0), UnitLiter, UnitFluidOunce)
+	require.NoError(t, err)
+	require.Equal(t, "67.63", amount.StringFixed(2))
+}
+
+func Test_Convert_CountToEach_ReturnsSameAmount(t *testing.T) {
+	amount, err := Convert(decimal.New(12, 0), UnitCount, UnitEach)
+	require.NoError(t, err)
+	require.Equal(t, "12", amount.String())
+}
+
+func Test_Convert_WeightToVolume_ReturnsError(t *testing.T) {
+	_, err := Convert(decimal.New(12, 0), UnitOunce, UnitFluidOunce)
+	require.Error(t, err)
+	require.Equal(t, ErrIncompatibleUnits, errors.Cause(err))
+}
@@ -0,0 +1,166 @@
+package product
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Rounding is a way of rounding a unit price to its decimal places.
+type Rounding string
+
+const (
+	// RoundHalfUp rounds halves away from zero.
+	RoundHalfUp Rounding = "half_up"
+
+	// RoundHalfEven rounds halves to the even digit.
+	RoundHalfEven Rounding = "half_even"
+
+	// RoundUp rounds towards positive infinity.
+	RoundUp Rounding = "up"
+
+	// RoundDown rounds towards negative infinity.
+	RoundDown Rounding = "down"
+)
+
+// Basis is the measure a unit price is the price of, such as 1 oz or 100 g.
+type Basis struct {
+	Amount decimal.Decimal `json:"amount"`
+	Unit   UnitOfMeasure   `json:"unit"`
+}
+
+// IsZero returns true for the basis of a product without a unit price.
+func (b Basis) IsZero() bool {
+	return b.Unit == ""
+}
+
+func (b Basis) String() string {
+	if b.IsZero() {
+		return ""
+	}
+	if b.Amount.Equal(decimal.New(1, 0)) {
+		return b.Unit.Symbol()
+	}
+	return fmt.Sprintf("%s %s", b.Amount, b.Unit.Symbol())
+}
+
+// UnitPricing is a jurisdiction's rules for unit prices: the basis each dimension of size is priced per,
+// and how unit prices are rounded.
+type UnitPricing struct {
+	Weight Basis
+	Volume Basis
+	Count  Basis
+
+	// Places is the number of decimal places of a unit price, rounded by Rounding.
+	Places   int32
+	Rounding Rounding
+}
+
+var (
+	// UnitPricingUS prices per ounce, fluid ounce and count, rounded half up to a tenth of a cent.
+	UnitPricingUS = UnitPricing{
+		Weight:   Basis{decimal.New(1, 0), UnitOunce},
+		Volume:   Basis{decimal.New(1, 0), UnitFluidOunce},
+		Count:    Basis{decimal.New(1, 0), UnitCount},
+		Places:   3,
+		Rounding: RoundHalfUp,
+	}
+
+	// UnitPricingEU prices per kilogram, liter and count, rounded half up to the cent.
+	UnitPricingEU = UnitPricing{
+		Weight:   Basis{decimal.New(1, 0), UnitKilogram},
+		Volume:   Basis{decimal.New(1, 0), UnitLiter},
+		Count:    Basis{decimal.New(1, 0), UnitCount},
+		Places:   2,
+		Rounding: RoundHalfUp,
+	}
+
+	// unitPricings are the unit pricing rules by jurisdiction.
+	unitPricings = map[string]UnitPricing{
+		"US": UnitPricingUS,
+		"EU": UnitPricingEU,
+	}
+)
+
+// UnitPricingFor returns the unit pricing rules of a jurisdiction, "US" or "EU".
+func UnitPricingFor(jurisdiction string) (UnitPricing, error) {
+	u, ok := unitPricings[strings.ToUpper(jurisdiction)]
+	if !ok {
+		return UnitPricing{}, errors.Wrapf(ErrBadParameter, "unknown unit pricing jurisdiction %q", jurisdiction)
+	}
+	return u, nil
+}
+
+// Validate checks that each basis is a positive amount of a unit of its dimension, and the rounding is known.
+func (u UnitPricing) Validate() error {
+	for _, b := range []struct {
+		basis     Basis
+		dimension Dimension
+	}{
+		{u.Weight, DimensionWeight},
+		{u.Volume, DimensionVolume},
+		{u.Count, DimensionCount},
+	} {
+		if b.basis.Unit.Dimension() != b.dimension || !b.basis.Amount.IsPositive() {
+			return errors.Wrapf(ErrBadParameter, "bad %s unit price basis %q", b.dimension, b.basis)
+		}
+	}
+
+	switch u.Rounding {
+	case RoundHalfUp, RoundHalfEven, RoundUp, RoundDown:
+	default:
+		return errors.Wrapf(ErrBadParameter, "unknown unit price rounding %q", u.Rounding)
+	}
+	if u.Places < 0 {
+		return errors.Wrapf(ErrBadParameter, "negative unit price places %d", u.Places)
+	}
+
+	return nil
+}
+
+// Basis returns the basis of the unit price of a quantity of the given unit.
+func (u UnitPricing) Basis(unit UnitOfMeasure) (Basis, error) {
+	switch unit.Dimension() {
+	case DimensionWeight:
+		return u.Weight, nil
+	case DimensionVolume:
+		return u.Volume, nil
+	case DimensionCount:
+		return u.Count, nil
+	}
+	return Basis{}, errors.Wrapf(ErrIncompatibleUnits, "no unit price basis for %q", unit)
+}
+
+// UnitPrice returns the price of a basis amount of a product, given the price of a quantity of it.
+func (u UnitPricing) UnitPrice(price decimal.Decimal, q Quantity) (decimal.Decimal, Basis, error) {
+	if q.IsZero() || !q.Amount.IsPositive() {
+		return decimal.Zero, Basis{}, errors.Wrap(ErrBadParameter, "no quantity to unit price")
+	}
+
+	basis, err := u.Basis(q.Unit)
+	if err != nil {
+		return decimal.Zero, Basis{}, err
+	}
+
+	total, err := Convert(q.Total(), q.Unit, basis.Unit)
+	if err != nil {
+		return decimal.Zero, Basis{}, err
+	}
+
+	return u.round(price.Mul(basis.Amount).Div(total)), basis, nil
+}
+
+// round rounds a unit price to the configured places.
+func (u UnitPricing) round(d decimal.Decimal) decimal.Decimal {
+	switch u.Rounding {
+	case RoundHalfEven:
+		return d.RoundBank(u.Places)
+	case RoundUp:
+		return d.Shift(u.Places).Ceil().Shift(-u.Places)
+	case RoundDown:
+		return d.Shift(u.Places).Floor().Shift(-u.Places)
+	}
+	return d.Round(u.Places)
+}
@@ -0,0 +1,95 @@
+package product
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_UnitPrice_US_PricesPerOunce(t *testing.T) {
+	q, _ := ParseSize("18oz")
+	price, basis, err := UnitPricingUS.UnitPrice(decimal.New(567, -2), q)
+	require.NoError(t, err)
+	require.Equal(t, "0.315", price.String())
+	require.Equal(t, "oz", basis.String())
+}
+
+func Test_UnitPrice_Pack_PricesWholePack(t *testing.T) {
+	q, _ := ParseSize("12x12oz")
+	price, _, err := UnitPricingUS.UnitPrice(decimal.New(65, -1), q)
+	require.NoError(t, err)
+	require.Equal(t, "0.045", price.String())
+}
+
+func Test_UnitPrice_PerPound_PricesPerOunce(t *testing.T) {
+	q, _ := ParseSize("lb")
+	price, _, err := UnitPricingUS.UnitPrice(decimal.New(349, -2), q)
+	require.NoError(t, err)
+	require.Equal(t, "0.218", price.String())
+}
+
+func Test_UnitPrice_EU_PricesPerKilogram(t *testing.T) {
+	q, _ := ParseSize("500g")
+	price, basis, err := UnitPricingEU.UnitPrice(decimal.New(199, -2), q)
+	require.NoError(t, err)
+	require.Equal(t, "3.98", price.String())
+	require.Equal(t, "kg", basis.String())
+}
+
+func Test_UnitPrice_Per100Grams_PricesBasisAmount(t *testing.T) {
+	u := UnitPricingEU
+	u.Weight = Basis{decimal.New(100, 0), UnitGram}
+
+	q, _ := ParseSize("250g")
+	price, basis, err := u.UnitPrice(decimal.New(299, -2), q)
+	require.NoError(t, err)
+	require.Equal(t, "1.2", price.String())
+	require.Equal(t, "100 g", basis.String())
+}
+
+func Test_UnitPrice_Rounding_RoundsConfiguredWay(t *testing.T) {
+	q, _ := ParseSize("8ct")
+	price := decimal.New(1, 0) // 0.125 per count
+
+	for rounding, expected := range map[Rounding]string{
+		RoundHalfUp:   "0.13",
+		RoundHalfEven: "0.12",
+		RoundUp:       "0.13",
+		RoundDown:     "0.12",
+	} {
+		u := UnitPricingEU
+		u.Rounding = rounding
+		result, _, err := u.UnitPrice(price, q)
+		require.NoError(t, err)
+		require.Equal(t, expected, result.String(), string(rounding))
+	}
+}
+
+func Test_UnitPrice_NoQuantity_ReturnsError(t *testing.T) {
+	_, _, err := UnitPricingUS.UnitPrice(decimal.New(1, 0), Quantity{})
+	require.Error(t, err)
+	require.Equal(t, ErrBadParameter, errors.Cause(err))
+}
+
+func Test_UnitPricingFor_KnownJurisdiction_ReturnsRules(t *testing.T) {
+	u, err := UnitPricingFor("eu")
+	require.NoError(t, err)
+	require.Equal(t, UnitKilogram, u.Weight.Unit)
+
+	_, err = UnitPricingFor("Mars")
+	require.Equal(t, ErrBadParameter, errors.Cause(err))
+}
+
+func Test_UnitPricing_Validate_BadBasis_ReturnsError(t *testing.T) {
+	require.NoError(t, UnitPricingUS.Validate())
+
+	u := UnitPricingUS
+	u.Weight = Basis{decimal.New(1, 0), UnitLiter}
+	require.Equal(t, ErrBadParameter, errors.Cause(u.Validate()))
+
+	u = UnitPricingUS
+	u.Rounding = "sideways"
+	require.Equal(t, ErrBadParameter, errors.Cause(u.Validate()))
+}
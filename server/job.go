@@ -0,0 +1,138 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/jessejohnston/ProductIngester/product"
+)
+
+// MaxJobErrors is the number of line errors listed in a job's status. Stats.Errors counts them all.
+const MaxJobErrors = 1000
+
+// Status is the state of an ingestion job.
+type Status string
+
+const (
+	// StatusRunning is the status of a job whose file is still being parsed.
+	StatusRunning Status = "running"
+
+	// StatusSucceeded is the status of a job whose file was parsed within its error budget.
+	StatusSucceeded Status = "succeeded"
+
+	// StatusFailed is the status of a job that stopped early, such as by exceeding its error budget.
+	StatusFailed Status = "failed"
+)
+
+// JobStatus describes an ingestion job. Stats and Finished are only set once the job has finished.
+type JobStatus struct {
+	ID       string        `json:"id"`
+	Source   string        `json:"source,omitempty"`
+	Status   Status        `json:"status"`
+	Started  time.Time     `json:"started"`
+	Finished *time.Time    `json:"finished,omitempty"`
+	Records  int           `json:"records"`
+	Stats    *parser.Stats `json:"stats,omitempty"`
+	Errors   []LineError   `json:"errors"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// LineError describes a line that failed to parse.
+type LineError struct {
+	Line    int         `json:"line"`
+	Column  int         `json:"column"`
+	Field   string      `json:"field,omitempty"`
+	Text    string      `json:"text,omitempty"`
+	Code    parser.Code `json:"code"`
+	Message string      `json:"message"`
+}
+
+// newLineError describes the failure of a result.
+func newLineError(r parser.Result) LineError {
+	le := LineError{Line: r.Line, Code: parser.CodeOf(r.Err), Message: r.Err.Error()}
+	if e, ok := r.Err.(parser.Error); ok {
+		le.Column = e.Column()
+		le.Field = e.FieldName()
+		le.Text = string(e.Field())
+		le.Message = fmt.Sprintf("%s: %v", e.Message(), e.Unwrap())
+	}
+	return le
+}
+
+// job is an ingestion job, updated as its file is parsed and read by status requests.
+type job struct {
+	mu      sync.Mutex
+	status  JobStatus
+	records []*product.Record
+}
+
+func newJob(id, source string) *job {
+	return &job{status: JobStatus{
+		ID:      id,
+		Source:  source,
+		Status:  StatusRunning,
+		Started: time.Now(),
+		Errors:  []LineError{},
+	}}
+}
+
+// add records the result of parsing a line.
+func (j *job) add(r parser.Result) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if r.Err != nil {
+		if len(j.status.Errors) < MaxJobErrors {
+			j.status.Errors = append(j.status.Errors, newLineError(r))
+		}
+		return
+	}
+	j.records = append(j.records, r.Record)
+	j.status.Records++
+}
+
+// finish records the outcome of the parse run. The records of a failed job are discarded.
+func (j *job) finish(stats parser.Stats, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	finished := time.Now()
+	j.status.Finished = &finished
+	j.status.Stats = &stats
+	j.status.Status = StatusSucceeded
+	if err != nil {
+		j.status.Status = StatusFailed
+		j.status.Error = err.Error()
+		j.records = nil
+	}
+}
+
+// finishedAt returns the time the job finished, and false if it is still running.
+func (j *job) finishedAt() (time.Time, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.status.Finished == nil {
+		return time.Time{}, false
+	}
+	return *j.status.Finished, true
+}
+
+// snapshot returns a copy of the job's status.
+func (j *job) snapshot() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	s := j.status
+	s.Errors = append([]LineError{}, j.status.Errors...)
+	return s
+}
+
+// results returns the job's status, and its records if it succeeded.
+func (j *job) results() (JobStatus, []*product.Record) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status, j.records
+}
@@ -0,0 +1,407 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/charset"
+	"github.com/jessejohnston/ProductIngester/export"
+	"github.com/jessejohnston/ProductIngester/input"
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/pkg/errors"
+)
+
+// ErrBadParameter is the error returned when invalid input is provided.
+var ErrBadParameter = errors.New("Invalid parameter")
+
+// Parser defines the behavior of a product catalog parser.
+type Parser interface {
+	Results(ctx context.Context) <-chan parser.Result
+	Err() error
+	Stats() parser.Stats
+}
+
+// NewParserFunc creates a parser of a catalog file uploaded from source, with any options the upload asks for.
+type NewParserFunc func(input io.Reader, source string, opts ...parser.Option) (Parser, error)
+
+const (
+	// DefaultMaxUploadBytes is the default size limit of an uploaded file, as received.
+	DefaultMaxUploadBytes = 1 << 30
+
+	// DefaultJobTTL is the default time a finished job is kept for.
+	DefaultJobTTL = time.Hour
+
+	// DefaultMaxJobs is the default number of finished jobs kept.
+	DefaultMaxJobs = 100
+)
+
+// Option configures optional server behavior.
+type Option func(*Server) error
+
+// WithMaxUploadBytes configures the size limit of an uploaded file, as received, instead of DefaultMaxUploadBytes.
+func WithMaxUploadBytes(n int64) Option {
+	return func(s *Server) error {
+		if n <= 0 {
+			return errors.WithStack(ErrBadParameter)
+		}
+		s.maxUploadBytes = n
+		return nil
+	}
+}
+
+// WithJobTTL configures how long a finished job is kept for, instead of DefaultJobTTL.
+func WithJobTTL(d time.Duration) Option {
+	return func(s *Server) error {
+		if d <= 0 {
+			return errors.WithStack(ErrBadParameter)
+		}
+		s.jobTTL = d
+		return nil
+	}
+}
+
+// WithMaxJobs configures the number of finished jobs kept, instead of DefaultMaxJobs.
+func WithMaxJobs(n int) Option {
+	return func(s *Server) error {
+		if n < 1 {
+			return errors.WithStack(ErrBadParameter)
+		}
+		s.maxJobs = n
+		return nil
+	}
+}
+
+// Server is an HTTP service that ingests catalog files:
+//
+//	POST /jobs?source=<name>   starts a job that parses the request body as a catalog file, returning its status;
+//	                           &charset=<name> sets the file's character encoding
+//	GET  /jobs/<id>            returns the status of a job, with its stats and line errors
+//	GET  /jobs/<id>/records    returns the records of a succeeded job; ?format=jsonl (default), csv or text,
+//	                           and ?columns=a,b,... for csv
+//
+// The upload is saved to a temporary file, which is parsed after POST returns. A gzip, zstd or bzip2 compressed
+// upload is decompressed. Jobs are kept in memory; a finished job is dropped once it is older than the job TTL,
+// or when more than the maximum number of finished jobs are kept, oldest first.
+type Server struct {
+	newParser      NewParserFunc
+	maxUploadBytes int64
+	jobTTL         time.Duration
+	maxJobs        int
+
+	// ctx ends the running jobs, which running counts.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// New creates a server that parses each uploaded file with a parser from newParser.
+func New(newParser NewParserFunc, opts ...Option) (*Server, error) {
+	if newParser == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+
+	s := &Server{
+		newParser:      newParser,
+		maxUploadBytes: DefaultMaxUploadBytes,
+		jobTTL:         DefaultJobTTL,
+		maxJobs:        DefaultMaxJobs,
+		jobs:           make(map[string]*job),
+	}
+	for _, opt := range opts {
+		if opt == nil {
+			return nil, errors.WithStack(ErrBadParameter)
+		}
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s, nil
+}
+
+// Shutdown waits for the running jobs to finish, stopping them if ctx ends first.
+// Call it after the HTTP server has stopped accepting uploads.
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return errors.WithStack(ctx.Err())
+	}
+}
+
+// ServeHTTP routes a request to its handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "jobs" || len(parts) > 3 || (len(parts) == 3 && parts[2] != "records") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	method := http.MethodGet
+	if len(parts) == 1 {
+		method = http.MethodPost
+	}
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	switch len(parts) {
+	case 1:
+		s.createJob(w, r)
+	case 2:
+		s.getJob(w, parts[1])
+	case 3:
+		s.getRecords(w, r, parts[1])
+	}
+}
+
+// createJob saves the request body to a temporary file, and starts a job that parses it.
+func (s *Server) createJob(w http.ResponseWriter, r *http.Request) {
+	id, err := newID()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var opts []parser.Option
+	if name := r.URL.Query().Get("charset"); name != "" {
+		cs, err := charset.Lookup(name)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		opts = append(opts, parser.WithCharset(cs))
+	}
+
+	upload, code, err := s.spool(w, r)
+	if err != nil {
+		writeError(w, code, err.Error())
+		return
+	}
+
+	// The job owns the upload once it starts; until then, it is removed here.
+	started := false
+	defer func() {
+		if !started {
+			removeUpload(upload)
+		}
+	}()
+
+	// Compressed uploads are decompressed as they are read. A zip archive can't be read as a stream.
+	body, _, err := input.NewReader(upload)
+	if errors.Cause(err) == input.ErrArchive {
+		writeError(w, http.StatusUnsupportedMediaType, "zip archives aren't supported, upload each file")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	source := r.URL.Query().Get("source")
+	p, err := s.newParser(body, source, opts...)
+	if err != nil {
+		body.Close()
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	j := newJob(id, source)
+	s.mu.Lock()
+	s.evict(time.Now())
+	s.jobs[id] = j
+	s.mu.Unlock()
+
+	started = true
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer removeUpload(upload)
+		defer body.Close()
+
+		for result := range p.Results(s.ctx) {
+			j.add(result)
+		}
+		j.finish(p.Stats(), p.Err())
+	}()
+
+	w.Header().Set("Location", "/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, j.snapshot())
+}
+
+// spool saves the request body to a temporary file, returning the file rewound to its start. On failure, it
+// returns the response code for the error.
+func (s *Server) spool(w http.ResponseWriter, r *http.Request) (*os.File, int, error) {
+	upload, err := ioutil.TempFile("", "ingester-upload-")
+	if err != nil {
+		return nil, http.StatusInternalServerError, errors.WithStack(err)
+	}
+
+	_, err = io.Copy(upload, http.MaxBytesReader(w, r.Body, s.maxUploadBytes))
+	if _, tooLarge := err.(*http.MaxBytesError); tooLarge {
+		removeUpload(upload)
+		return nil, http.StatusRequestEntityTooLarge, errors.Errorf("upload is larger than %d bytes", s.maxUploadBytes)
+	}
+	if err != nil {
+		removeUpload(upload)
+		return nil, http.StatusBadRequest, errors.WithStack(err)
+	}
+
+	if _, err := upload.Seek(0, io.SeekStart); err != nil {
+		removeUpload(upload)
+		return nil, http.StatusInternalServerError, errors.WithStack(err)
+	}
+	return upload, 0, nil
+}
+
+// removeUpload closes and deletes a spooled upload.
+func removeUpload(upload *os.File) {
+	upload.Close()
+	os.Remove(upload.Name())
+}
+
+func (s *Server) getJob(w http.ResponseWriter, id string) {
+	j := s.job(id)
+	if j == nil {
+		writeError(w, http.StatusNotFound, "no job "+id)
+		return
+	}
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+// contentTypes are the media types of the record formats.
+var contentTypes = map[string]string{
+	export.FormatText:      "text/plain; charset=utf-8",
+	export.FormatJSONLines: "application/x-ndjson",
+	export.FormatCSV:       "text/csv; charset=utf-8",
+}
+
+func (s *Server) getRecords(w http.ResponseWriter, r *http.Request, id string) {
+	j := s.job(id)
+	if j == nil {
+		writeError(w, http.StatusNotFound, "no job "+id)
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = export.FormatJSONLines
+	}
+
+	var output export.Writer
+	var err error
+	switch {
+	case format == export.FormatCSV:
+		var columns []string
+		if query.Get("columns") != "" {
+			columns = strings.Split(query.Get("columns"), ",")
+		}
+		output, err = export.NewCSVWriter(w, columns...)
+	case query.Get("columns") != "":
+		err = errors.Wrapf(ErrBadParameter, "columns given for %s format", format)
+	default:
+		output, err = export.NewWriter(format, w)
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status, records := j.results()
+	if status.Status != StatusSucceeded {
+		writeError(w, http.StatusConflict, "job "+id+" is "+string(status.Status))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypes[format])
+	for _, record := range records {
+		if err := output.Write(record); err != nil {
+			// The response has started, so the client sees a truncated body.
+			return
+		}
+	}
+	output.Flush()
+}
+
+func (s *Server) job(id string) *job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evict(time.Now())
+	return s.jobs[id]
+}
+
+// evict drops the finished jobs that are older than the job TTL, and then the oldest finished jobs beyond the
+// maximum kept. Running jobs are kept. s.mu must be held.
+func (s *Server) evict(now time.Time) {
+	type finishedJob struct {
+		id string
+		at time.Time
+	}
+
+	var finished []finishedJob
+	for id, j := range s.jobs {
+		at, ok := j.finishedAt()
+		if !ok {
+			continue
+		}
+		if now.Sub(at) > s.jobTTL {
+			delete(s.jobs, id)
+			continue
+		}
+		finished = append(finished, finishedJob{id, at})
+	}
+
+	if len(finished) <= s.maxJobs {
+		return
+	}
+	sort.Slice(finished, func(a, b int) bool { return finished[a].at.Before(finished[b].at) })
+	for _, f := range finished[:len(finished)-s.maxJobs] {
+		delete(s.jobs, f.id)
+	}
+}
+
+// newID returns a random job ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,360 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type serverTestSuite struct {
+	suite.Suite
+	handler *Server
+	server  *httptest.Server
+	sample  string
+	options []parser.Option
+
+	// hold, if set, keeps each job from reading its upload until it is closed.
+	hold chan struct{}
+}
+
+func Test_Server(t *testing.T) {
+	s := new(serverTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *serverTestSuite) SetupTest() {
+	sample, err := ioutil.ReadFile("../cmd/ingester/input-sample.txt")
+	require.NoError(s.T(), err)
+	s.sample = string(sample)
+	s.options = nil
+	s.hold = nil
+	s.start()
+}
+
+func (s *serverTestSuite) TearDownTest() {
+	s.stop()
+}
+
+// start serves a new server with the options.
+func (s *serverTestSuite) start(opts ...Option) {
+	handler, err := New(func(input io.Reader, source string, opts ...parser.Option) (Parser, error) {
+		convert, err := product.NewConverter(parser.NumberFieldLength, parser.CurrencyFieldLength, parser.FlagsFieldLength)
+		if err != nil {
+			return nil, err
+		}
+		if s.hold != nil {
+			input = &heldReader{Reader: input, hold: s.hold}
+		}
+		return parser.New(input, convert, append(s.options, opts...)...)
+	}, opts...)
+	require.NoError(s.T(), err)
+	s.handler = handler
+	s.server = httptest.NewServer(handler)
+}
+
+// stop closes the server, waiting for its jobs to finish.
+func (s *serverTestSuite) stop() {
+	if s.hold != nil {
+		select {
+		case <-s.hold:
+		default:
+			close(s.hold)
+		}
+	}
+	s.server.Close()
+	require.NoError(s.T(), s.handler.Shutdown(context.Background()))
+}
+
+// heldReader blocks reads until hold is closed.
+type heldReader struct {
+	io.Reader
+	hold chan struct{}
+}
+
+func (r *heldReader) Read(p []byte) (int, error) {
+	<-r.hold
+	return r.Reader.Read(p)
+}
+
+// post uploads a catalog file, returning the job's status once it finishes.
+func (s *serverTestSuite) post(body string) JobStatus {
+	return s.postQuery(body, "source=monday.txt")
+}
+
+// postQuery uploads a catalog file with query parameters, returning the job's status once it finishes.
+func (s *serverTestSuite) postQuery(body, query string) JobStatus {
+	return s.wait(s.startJob(body, query).ID)
+}
+
+// startJob uploads a catalog file with query parameters, returning the status of the job it starts.
+func (s *serverTestSuite) startJob(body, query string) JobStatus {
+	resp, err := http.Post(s.server.URL+"/jobs?"+query, "text/plain", strings.NewReader(body))
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+	require.Equal(s.T(), http.StatusAccepted, resp.StatusCode)
+
+	var status JobStatus
+	require.NoError(s.T(), json.NewDecoder(resp.Body).Decode(&status))
+	require.Equal(s.T(), "/jobs/"+status.ID, resp.Header.Get("Location"))
+	return status
+}
+
+// wait polls a job until it finishes, returning its status.
+func (s *serverTestSuite) wait(id string) JobStatus {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(time.Millisecond) {
+		code, _, body := s.get("/jobs/" + id)
+		require.Equal(s.T(), http.StatusOK, code)
+
+		var status JobStatus
+		require.NoError(s.T(), json.Unmarshal([]byte(body), &status))
+		if status.Status != StatusRunning {
+			return status
+		}
+	}
+	require.FailNow(s.T(), "job "+id+" didn't finish")
+	return JobStatus{}
+}
+
+// get fetches a path, returning the response code, content type and body.
+func (s *serverTestSuite) get(path string) (int, string, string) {
+	resp, err := http.Get(s.server.URL + path)
+	require.NoError(s.T(), err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(s.T(), err)
+	return resp.StatusCode, resp.Header.Get("Content-Type"), string(body)
+}
+
+func (s *serverTestSuite) Test_New_NoParserFunc_ReturnsError() {
+	_, err := New(nil)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *serverTestSuite) Test_New_BadOption_ReturnsError() {
+	newParser := func(io.Reader, string, ...parser.Option) (Parser, error) { return nil, nil }
+
+	for _, opt := range []Option{nil, WithMaxUploadBytes(0), WithJobTTL(0), WithMaxJobs(0)} {
+		_, err := New(newParser, opt)
+		require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+	}
+}
+
+func (s *serverTestSuite) Test_PostJob_ReturnsRunningJob() {
+	s.hold = make(chan struct{})
+
+	status := s.startJob(s.sample, "source=monday.txt")
+	require.Equal(s.T(), StatusRunning, status.Status)
+	require.Nil(s.T(), status.Finished)
+
+	code, _, _ := s.get("/jobs/" + status.ID + "/records")
+	require.Equal(s.T(), http.StatusConflict, code)
+
+	close(s.hold)
+	status = s.wait(status.ID)
+	require.Equal(s.T(), StatusSucceeded, status.Status)
+	require.Equal(s.T(), 4, status.Records)
+}
+
+func (s *serverTestSuite) Test_PostJob_TooLarge_ReturnsRequestEntityTooLarge() {
+	s.stop()
+	s.start(WithMaxUploadBytes(int64(len(s.sample) - 1)))
+
+	resp, err := http.Post(s.server.URL+"/jobs", "text/plain", strings.NewReader(s.sample))
+	require.NoError(s.T(), err)
+	resp.Body.Close()
+	require.Equal(s.T(), http.StatusRequestEntityTooLarge, resp.StatusCode)
+}
+
+func (s *serverTestSuite) Test_FinishedJobs_OverMaxJobs_EvictsOldest() {
+	s.stop()
+	s.start(WithMaxJobs(1))
+
+	first := s.post(s.sample)
+	second := s.post(s.sample)
+
+	code, _, _ := s.get("/jobs/" + first.ID)
+	require.Equal(s.T(), http.StatusNotFound, code)
+	code, _, _ = s.get("/jobs/" + second.ID)
+	require.Equal(s.T(), http.StatusOK, code)
+}
+
+func (s *serverTestSuite) Test_FinishedJob_OlderThanTTL_IsEvicted() {
+	s.stop()
+	s.start(WithJobTTL(50 * time.Millisecond))
+	s.hold = make(chan struct{})
+
+	status := s.startJob(s.sample, "source=monday.txt")
+	time.Sleep(100 * time.Millisecond)
+
+	// A running job is kept, however long it runs.
+	code, _, _ := s.get("/jobs/" + status.ID)
+	require.Equal(s.T(), http.StatusOK, code)
+
+	close(s.hold)
+	s.wait(status.ID)
+	time.Sleep(100 * time.Millisecond)
+
+	code, _, _ = s.get("/jobs/" + status.ID)
+	require.Equal(s.T(), http.StatusNotFound, code)
+}
+
+func (s *serverTestSuite) Test_PostJob_Sample_Succeeds() {
+	status := s.post(s.sample)
+
+	require.Equal(s.T(), StatusSucceeded, status.Status)
+	require.Equal(s.T(), "monday.txt", status.Source)
+	require.Equal(s.T(), 4, status.Records)
+	require.NotNil(s.T(), status.Stats)
+	require.Equal(s.T(), 4, status.Stats.Records)
+	require.NotNil(s.T(), status.Finished)
+	require.Empty(s.T(), status.Errors)
+}
+
+func (s *serverTestSuite) Test_PostJob_Gzip_Decompresses() {
+	var body bytes.Buffer
+	w := gzip.NewWriter(&body)
+	w.Write([]byte(s.sample))
+	require.NoError(s.T(), w.Close())
+
+	status := s.post(body.String())
+	require.Equal(s.T(), StatusSucceeded, status.Status)
+	require.Equal(s.T(), 4, status.Records)
+}
+
+func (s *serverTestSuite) Test_PostJob_Zip_ReturnsUnsupported() {
+	resp, err := http.Post(s.server.URL+"/jobs", "application/zip", strings.NewReader("PK\x03\x04"))
+	require.NoError(s.T(), err)
+	resp.Body.Close()
+	require.Equal(s.T(), http.StatusUnsupportedMediaType, resp.StatusCode)
+}
+
+func (s *serverTestSuite) Test_PostJob_Charset_DecodesFile() {
+	sample := strings.Replace(s.sample, "Kimchi-flavored white rice", "Kimchi-flavored jalape\xf1os ", 1)
+
+	status := s.postQuery(sample, "charset=windows-1252")
+	require.Equal(s.T(), StatusSucceeded, status.Status)
+	require.Empty(s.T(), status.Errors)
+
+	_, _, body := s.get("/jobs/" + status.ID + "/records?format=csv&columns=description")
+	require.Contains(s.T(), body, "Kimchi-flavored jalapeños\n")
+}
+
+func (s *serverTestSuite) Test_PostJob_UTF8_ListsEncodingError() {
+	sample := strings.Replace(s.sample, "Kimchi-flavored white rice", "Kimchi-flavored jalape\xf1os ", 1)
+
+	status := s.post(sample)
+	require.Len(s.T(), status.Errors, 1)
+	require.Equal(s.T(), parser.CodeBadEncoding, status.Errors[0].Code)
+	require.Equal(s.T(), parser.FieldDescription, status.Errors[0].Field)
+}
+
+func (s *serverTestSuite) Test_PostJob_UnknownCharset_ReturnsBadRequest() {
+	resp, err := http.Post(s.server.URL+"/jobs?charset=klingon", "text/plain", strings.NewReader(s.sample))
+	require.NoError(s.T(), err)
+	resp.Body.Close()
+	require.Equal(s.T(), http.StatusBadRequest, resp.StatusCode)
+}
+
+func (s *serverTestSuite) Test_GetJob_ReturnsStatus() {
+	posted := s.post(s.sample)
+
+	code, contentType, body := s.get("/jobs/" + posted.ID)
+	require.Equal(s.T(), http.StatusOK, code)
+	require.Equal(s.T(), "application/json", contentType)
+
+	var status JobStatus
+	require.NoError(s.T(), json.Unmarshal([]byte(body), &status))
+	require.Equal(s.T(), posted.ID, status.ID)
+	require.Equal(s.T(), StatusSucceeded, status.Status)
+}
+
+func (s *serverTestSuite) Test_GetJob_Unknown_ReturnsNotFound() {
+	code, _, _ := s.get("/jobs/nope")
+	require.Equal(s.T(), http.StatusNotFound, code)
+}
+
+func (s *serverTestSuite) Test_PostJob_BadLine_ListsError() {
+	lines := strings.Split(s.sample, "\n")
+	lines[1] = strings.Replace(lines[1], "00000549", "0000054X", 1)
+	status := s.post(strings.Join(lines, "\n"))
+
+	require.Equal(s.T(), StatusSucceeded, status.Status)
+	require.Equal(s.T(), 3, status.Records)
+	require.Len(s.T(), status.Errors, 1)
+	require.Equal(s.T(), 2, status.Errors[0].Line)
+	require.Equal(s.T(), parser.CodeBadFormat, status.Errors[0].Code)
+	require.Equal(s.T(), "0000054X", status.Errors[0].Text)
+}
+
+func (s *serverTestSuite) Test_PostJob_OverErrorBudget_Fails() {
+	s.options = []parser.Option{parser.WithStopOnError()}
+	status := s.post("not a catalog\n")
+
+	require.Equal(s.T(), StatusFailed, status.Status)
+	require.NotEmpty(s.T(), status.Error)
+
+	code, _, _ := s.get("/jobs/" + status.ID + "/records")
+	require.Equal(s.T(), http.StatusConflict, code)
+}
+
+func (s *serverTestSuite) Test_GetRecords_JSONLines_ReturnsRecordPerLine() {
+	status := s.post(s.sample)
+
+	code, contentType, body := s.get("/jobs/" + status.ID + "/records")
+	require.Equal(s.T(), http.StatusOK, code)
+	require.Equal(s.T(), "application/x-ndjson", contentType)
+
+	lines := strings.Split(strings.TrimSpace(body), "\n")
+	require.Len(s.T(), lines, 4)
+
+	var r product.Record
+	require.NoError(s.T(), json.Unmarshal([]byte(lines[0]), &r))
+	require.Equal(s.T(), 80000001, r.ID)
+}
+
+func (s *serverTestSuite) Test_GetRecords_CSV_ReturnsColumns() {
+	status := s.post(s.sample)
+
+	code, contentType, body := s.get("/jobs/" + status.ID + "/records?format=csv&columns=id,price")
+	require.Equal(s.T(), http.StatusOK, code)
+	require.Equal(s.T(), "text/csv; charset=utf-8", contentType)
+	require.True(s.T(), strings.HasPrefix(body, "id,price\n80000001,5.67\n"), body)
+}
+
+func (s *serverTestSuite) Test_GetRecords_BadFormat_ReturnsBadRequest() {
+	status := s.post(s.sample)
+
+	code, _, _ := s.get("/jobs/" + status.ID + "/records?format=xml")
+	require.Equal(s.T(), http.StatusBadRequest, code)
+
+	code, _, _ = s.get("/jobs/" + status.ID + "/records?format=csv&columns=colour")
+	require.Equal(s.T(), http.StatusBadRequest, code)
+}
+
+func (s *serverTestSuite) Test_WrongMethod_ReturnsMethodNotAllowed() {
+	resp, err := http.Get(s.server.URL + "/jobs")
+	require.NoError(s.T(), err)
+	resp.Body.Close()
+	require.Equal(s.T(), http.StatusMethodNotAllowed, resp.StatusCode)
+	require.Equal(s.T(), http.MethodPost, resp.Header.Get("Allow"))
+}
+
+func (s *serverTestSuite) Test_UnknownPath_ReturnsNotFound() {
+	code, _, _ := s.get("/catalogs")
+	require.Equal(s.T(), http.StatusNotFound, code)
+}
@@ -0,0 +1,139 @@
+package sink
+
+import (
+	"io/ioutil"
+
+	"github.com/jessejohnston/ProductIngester/export"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// TypeStdout writes records to standard output.
+	TypeStdout = "stdout"
+
+	// TypeFile writes records to a file.
+	TypeFile = "file"
+
+	// TypeStore upserts records into a catalog store.
+	TypeStore = "store"
+)
+
+// ErrBadConfig is the error returned when a sink configuration is invalid.
+var ErrBadConfig = errors.New("Invalid sink configuration")
+
+// Config is the set of sinks parsed records are delivered to.
+type Config struct {
+	Sinks []Destination `json:"sinks" yaml:"sinks"`
+}
+
+// Destination configures one sink. Format and Columns are used by stdout and file sinks, and Path by file and
+// store sinks. The format defaults to text.
+type Destination struct {
+	Type    string   `json:"type" yaml:"type"`
+	Format  string   `json:"format" yaml:"format"`
+	Columns []string `json:"columns" yaml:"columns"`
+	Path    string   `json:"path" yaml:"path"`
+}
+
+// LoadConfig reads and validates a sink configuration from a YAML (or JSON) file.
+func LoadConfig(filename string) (*Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var c Config
+	if err := yaml.UnmarshalStrict(data, &c); err != nil {
+		return nil, errors.Wrap(ErrBadConfig, err.Error())
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Validate checks that there is at least one sink, and each has a known type and the settings it needs.
+func (c *Config) Validate() error {
+	if len(c.Sinks) == 0 {
+		return errors.Wrap(ErrBadConfig, "no sinks")
+	}
+
+	for i, d := range c.Sinks {
+		if err := d.validate(); err != nil {
+			return errors.Wrapf(err, "sink %d", i+1)
+		}
+	}
+	return nil
+}
+
+func (d Destination) validate() error {
+	switch d.Type {
+	case TypeStdout, TypeFile:
+		format := d.format()
+		if !export.IsFormat(format) {
+			return errors.Wrapf(ErrBadConfig, "unknown format %q", format)
+		}
+		if len(d.Columns) > 0 {
+			if format != export.FormatCSV {
+				return errors.Wrapf(ErrBadConfig, "columns given for %s format", format)
+			}
+			if err := export.CheckCSVColumns(d.Columns...); err != nil {
+				return errors.Wrap(ErrBadConfig, err.Error())
+			}
+		}
+		if d.Type == TypeFile && d.Path == "" {
+			return errors.Wrap(ErrBadConfig, "no file path")
+		}
+	case TypeStore:
+		if d.Path == "" {
+			return errors.Wrap(ErrBadConfig, "no store path")
+		}
+	default:
+		return errors.Wrapf(ErrBadConfig, "unknown sink type %q", d.Type)
+	}
+	return nil
+}
+
+func (d Destination) format() string {
+	if d.Format == "" {
+		return export.FormatText
+	}
+	return d.Format
+}
+
+// Open opens every configured sink, and returns a sink that fans records out to them. Records delivered to a
+// store are recorded as seen in source. If any sink fails to open, those already opened are aborted.
+func (c *Config) Open(source string) (Sink, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	sinks := make([]Sink, 0, len(c.Sinks))
+	for _, d := range c.Sinks {
+		s, err := d.open(source)
+		if err != nil {
+			Fanout(sinks...).Abort()
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return Fanout(sinks...), nil
+}
+
+func (d Destination) open(source string) (Sink, error) {
+	switch d.Type {
+	case TypeStdout:
+		return Stdout(d.format(), d.Columns...)
+	case TypeFile:
+		return File(d.Path, d.format(), d.Columns...)
+	case TypeStore:
+		return OpenStore(d.Path, source)
+	}
+	return nil, errors.Wrapf(ErrBadConfig, "unknown sink type %q", d.Type)
+}
@@ -0,0 +1,96 @@
+package sink
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type configTestSuite struct {
+	suite.Suite
+	dir string
+}
+
+func Test_Config(t *testing.T) {
+	s := new(configTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *configTestSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "sink")
+	require.NoError(s.T(), err)
+}
+
+func (s *configTestSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *configTestSuite) writeConfig(text string) string {
+	filename := filepath.Join(s.dir, "sinks.yaml")
+	require.NoError(s.T(), ioutil.WriteFile(filename, []byte(text), 0644))
+	return filename
+}
+
+func (s *configTestSuite) Test_LoadConfig_SampleFile_ReadsSinks() {
+	c, err := LoadConfig("../cmd/ingester/sinks-sample.yaml")
+	require.NoError(s.T(), err)
+	require.Len(s.T(), c.Sinks, 3)
+	require.Equal(s.T(), TypeFile, c.Sinks[1].Type)
+	require.Equal(s.T(), []string{"id", "description", "price"}, c.Sinks[1].Columns)
+}
+
+func (s *configTestSuite) Test_LoadConfig_UnknownField_ReturnsError() {
+	_, err := LoadConfig(s.writeConfig("sinks:\n  - {type: stdout, colour: red}\n"))
+	require.Equal(s.T(), ErrBadConfig, errors.Cause(err))
+}
+
+func (s *configTestSuite) Test_Validate_NoSinks_ReturnsError() {
+	require.Equal(s.T(), ErrBadConfig, errors.Cause((&Config{}).Validate()))
+}
+
+func (s *configTestSuite) Test_Validate_BadSinks_ReturnError() {
+	for _, d := range []Destination{
+		{Type: "kafka"},
+		{Type: TypeStdout, Format: "xml"},
+		{Type: TypeStdout, Format: "jsonl", Columns: []string{"id"}},
+		{Type: TypeStdout, Format: "csv", Columns: []string{"colour"}},
+		{Type: TypeFile, Format: "csv"},
+		{Type: TypeStore},
+	} {
+		c := &Config{Sinks: []Destination{d}}
+		require.Equal(s.T(), ErrBadConfig, errors.Cause(c.Validate()), "%+v", d)
+	}
+}
+
+func (s *configTestSuite) Test_Open_FileAndStore_DeliversToBoth() {
+	csvPath := filepath.Join(s.dir, "catalog.csv")
+	dbPath := filepath.Join(s.dir, "catalog.db")
+	c := &Config{Sinks: []Destination{
+		{Type: TypeFile, Format: "csv", Columns: []string{"id"}, Path: csvPath},
+		{Type: TypeStore, Path: dbPath},
+	}}
+
+	sink, err := c.Open("monday.txt")
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), sink.Write(context.Background(), record(1, 100)))
+	require.NoError(s.T(), sink.Close())
+
+	data, err := ioutil.ReadFile(csvPath)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "id\n1\n", string(data))
+
+	st, err := store.Open(dbPath)
+	require.NoError(s.T(), err)
+	defer st.Close()
+	e, err := st.Get(1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "monday.txt", e.Source)
+}
@@ -0,0 +1,78 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+)
+
+// ErrBadParameter is the error returned when invalid input is provided.
+var ErrBadParameter = errors.New("Invalid parameter")
+
+// Sink is the behavior of a destination for parsed records. Records may be buffered until Flush is called.
+// Close flushes the sink and releases its resources. Abort releases its resources without flushing, discarding
+// buffered records, for a run that failed.
+type Sink interface {
+	Write(ctx context.Context, r *product.Record) error
+	Flush() error
+	Close() error
+	Abort() error
+}
+
+// fanout is a sink that delivers records to several sinks.
+type fanout []Sink
+
+// Fanout returns a sink that writes each record to all of the sinks, in order.
+func Fanout(sinks ...Sink) Sink {
+	return fanout(append([]Sink(nil), sinks...))
+}
+
+// Write writes the record to every sink, stopping at the first error.
+func (f fanout) Write(ctx context.Context, r *product.Record) error {
+	for _, s := range f {
+		if err := s.Write(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush flushes every sink, returning the first error.
+func (f fanout) Flush() error {
+	var first error
+	for _, s := range f {
+		if err := s.Flush(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Close flushes every sink, and then closes every sink, returning the first error. If any sink can't be flushed,
+// every sink is aborted instead, so that a file isn't replaced with records the store didn't take.
+func (f fanout) Close() error {
+	if err := f.Flush(); err != nil {
+		f.Abort()
+		return err
+	}
+
+	var first error
+	for _, s := range f {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Abort aborts every sink, returning the first error.
+func (f fanout) Abort() error {
+	var first error
+	for _, s := range f {
+		if err := s.Abort(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,119 @@
+package sink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// memorySink is a sink that keeps the records flushed to it.
+type memorySink struct {
+	pending []*product.Record
+	records []*product.Record
+	err     error
+	closed  bool
+	aborted bool
+}
+
+func (m *memorySink) Write(ctx context.Context, r *product.Record) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.pending = append(m.pending, r)
+	return nil
+}
+
+func (m *memorySink) Flush() error {
+	if m.err != nil {
+		return m.err
+	}
+	m.records = append(m.records, m.pending...)
+	m.pending = nil
+	return nil
+}
+
+func (m *memorySink) Close() error {
+	m.closed = true
+	return m.Flush()
+}
+
+func (m *memorySink) Abort() error {
+	m.aborted = true
+	m.pending = nil
+	return m.err
+}
+
+func record(id int, cents int64) *product.Record {
+	return &product.Record{ID: id, Description: "Rice", Price: decimal.New(cents, -2), Unit: product.UnitEach}
+}
+
+type sinkTestSuite struct {
+	suite.Suite
+}
+
+func Test_Sink(t *testing.T) {
+	s := new(sinkTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *sinkTestSuite) Test_Fanout_Write_DeliversToEverySink() {
+	a, b := &memorySink{}, &memorySink{}
+	f := Fanout(a, b)
+
+	require.NoError(s.T(), f.Write(context.Background(), record(1, 100)))
+	require.NoError(s.T(), f.Write(context.Background(), record(2, 200)))
+	require.NoError(s.T(), f.Flush())
+
+	require.Len(s.T(), a.records, 2)
+	require.Len(s.T(), b.records, 2)
+	require.Equal(s.T(), 2, b.records[1].ID)
+}
+
+func (s *sinkTestSuite) Test_Fanout_WriteError_ReturnsError() {
+	failed := errors.New("disk full")
+	f := Fanout(&memorySink{err: failed}, &memorySink{})
+
+	err := f.Write(context.Background(), record(1, 100))
+	require.Equal(s.T(), failed, errors.Cause(err))
+}
+
+func (s *sinkTestSuite) Test_Fanout_Close_ClosesEverySink() {
+	a, b := &memorySink{}, &memorySink{}
+	f := Fanout(a, b)
+
+	require.NoError(s.T(), f.Write(context.Background(), record(1, 100)))
+	require.NoError(s.T(), f.Close())
+	require.True(s.T(), a.closed)
+	require.True(s.T(), b.closed)
+	require.Len(s.T(), b.records, 1)
+}
+
+func (s *sinkTestSuite) Test_Fanout_Close_FlushFails_AbortsEverySink() {
+	failed := errors.New("disk full")
+	a, b := &memorySink{}, &memorySink{err: failed}
+
+	err := Fanout(a, b).Close()
+	require.Equal(s.T(), failed, errors.Cause(err))
+	require.True(s.T(), a.aborted)
+	require.True(s.T(), b.aborted)
+	require.False(s.T(), a.closed)
+	require.False(s.T(), b.closed)
+}
+
+func (s *sinkTestSuite) Test_Fanout_Abort_DiscardsPendingRecordsOfEverySink() {
+	a, b := &memorySink{}, &memorySink{}
+	f := Fanout(a, b)
+
+	require.NoError(s.T(), f.Write(context.Background(), record(1, 100)))
+	require.NoError(s.T(), f.Abort())
+
+	require.True(s.T(), a.aborted)
+	require.True(s.T(), b.aborted)
+	require.Empty(s.T(), a.records)
+	require.Empty(s.T(), b.pending)
+}
@@ -0,0 +1,80 @@
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/jessejohnston/ProductIngester/store"
+	"github.com/pkg/errors"
+)
+
+// storeSink is a sink that upserts records into a catalog store. It holds the records written to it until it is
+// flushed, so that they are upserted in a single transaction; a whole catalog is kept in memory until then.
+type storeSink struct {
+	store   *store.Store
+	owned   bool
+	source  string
+	seen    time.Time
+	records []*product.Record
+}
+
+// NewStore returns a sink that upserts records into a catalog store, as seen in source at the given time.
+// Records are buffered, and upserted in a single transaction by Flush. Closing the sink doesn't close the store.
+func NewStore(s *store.Store, source string, seen time.Time) (Sink, error) {
+	if s == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+	return &storeSink{store: s, source: source, seen: seen}, nil
+}
+
+// OpenStore returns a sink that upserts records into the catalog store in the file at path, as seen in source now.
+// The store is closed with the sink.
+func OpenStore(path, source string) (Sink, error) {
+	s, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &storeSink{store: s, owned: true, source: source, seen: time.Now()}, nil
+}
+
+func (s *storeSink) Write(ctx context.Context, r *product.Record) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	if r == nil {
+		return errors.WithStack(ErrBadParameter)
+	}
+	s.records = append(s.records, r)
+	return nil
+}
+
+func (s *storeSink) Flush() error {
+	if len(s.records) == 0 {
+		return nil
+	}
+	if err := s.store.Upsert(s.source, s.seen, s.records...); err != nil {
+		return err
+	}
+	s.records = nil
+	return nil
+}
+
+func (s *storeSink) Close() error {
+	err := s.Flush()
+	if s.owned {
+		if closeErr := s.store.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Abort discards the buffered records, so that none of a failed run's records are upserted.
+func (s *storeSink) Abort() error {
+	s.records = nil
+	if s.owned {
+		return s.store.Close()
+	}
+	return nil
+}
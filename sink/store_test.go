@@ -0,0 +1,113 @@
+package sink
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/export"
+	"github.com/jessejohnston/ProductIngester/store"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type storeTestSuite struct {
+	suite.Suite
+	dir   string
+	store *store.Store
+}
+
+func Test_Store(t *testing.T) {
+	s := new(storeTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *storeTestSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "sink")
+	require.NoError(s.T(), err)
+
+	s.store, err = store.Open(filepath.Join(s.dir, "catalog.db"))
+	require.NoError(s.T(), err)
+}
+
+func (s *storeTestSuite) TearDownTest() {
+	s.store.Close()
+	os.RemoveAll(s.dir)
+}
+
+func (s *storeTestSuite) Test_Write_BuffersUntilFlush() {
+	seen := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+	sink, err := NewStore(s.store, "monday.txt", seen)
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), sink.Write(context.Background(), record(1, 100)))
+	_, err = s.store.Get(1)
+	require.Equal(s.T(), store.ErrNotFound, errors.Cause(err))
+
+	require.NoError(s.T(), sink.Flush())
+	e, err := s.store.Get(1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "monday.txt", e.Source)
+	require.True(s.T(), seen.Equal(e.LastSeen))
+}
+
+func (s *storeTestSuite) Test_Close_FlushesAndLeavesStoreOpen() {
+	sink, _ := NewStore(s.store, "monday.txt", time.Now())
+
+	require.NoError(s.T(), sink.Write(context.Background(), record(1, 100)))
+	require.NoError(s.T(), sink.Close())
+
+	n, err := s.store.Count()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, n)
+}
+
+func (s *storeTestSuite) Test_Abort_DiscardsBufferedRecords() {
+	sink, _ := NewStore(s.store, "monday.txt", time.Now())
+
+	require.NoError(s.T(), sink.Write(context.Background(), record(1, 100)))
+	require.NoError(s.T(), sink.Abort())
+
+	n, err := s.store.Count()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 0, n)
+}
+
+func (s *storeTestSuite) Test_NewStore_NilStore_ReturnsError() {
+	_, err := NewStore(nil, "monday.txt", time.Now())
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *storeTestSuite) Test_Fanout_UpsertFails_LeavesFileAsItWas() {
+	t := s.T()
+
+	tuesday := time.Date(2026, 10, 6, 2, 0, 0, 0, time.UTC)
+	seeded, _ := NewStore(s.store, "tuesday.txt", tuesday)
+	require.NoError(t, seeded.Write(context.Background(), record(1, 100)))
+	require.NoError(t, seeded.Close())
+
+	path := filepath.Join(s.dir, "catalog.csv")
+	require.NoError(t, ioutil.WriteFile(path, []byte("id\n1\n"), 0644))
+	file, err := File(path, export.FormatCSV, "id")
+	require.NoError(t, err)
+
+	// Monday's file is older than the store's version of the product, so the upsert fails.
+	stale, _ := NewStore(s.store, "monday.txt", tuesday.AddDate(0, 0, -1))
+	out := Fanout(file, stale)
+	require.NoError(t, out.Write(context.Background(), record(2, 200)))
+	require.NoError(t, out.Write(context.Background(), record(1, 200)))
+
+	err = out.Close()
+	require.Equal(t, store.ErrOutOfOrder, errors.Cause(err))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "id\n1\n", string(data))
+	_, err = os.Stat(path + ".tmp")
+	require.True(t, os.IsNotExist(err))
+}
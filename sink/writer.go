@@ -0,0 +1,167 @@
+package sink
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/jessejohnston/ProductIngester/export"
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+)
+
+// writerSink is a sink that writes records with an export writer.
+type writerSink struct {
+	w      export.Writer
+	closer io.Closer
+}
+
+// NewWriter returns a sink that writes records with an export writer. If closer isn't nil, it is closed with the sink.
+// Records already written when the sink is aborted stay written.
+func NewWriter(w export.Writer, closer io.Closer) (Sink, error) {
+	if w == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+	return &writerSink{w: w, closer: closer}, nil
+}
+
+// Stdout returns a sink that writes records to standard output in an export format. Columns are only used by the
+// csv format. Standard output can't be taken back, so the records are held in a temporary file until the sink is
+// closed, and an aborted sink prints nothing.
+func Stdout(format string, columns ...string) (Sink, error) {
+	return spool(os.Stdout, format, columns)
+}
+
+// spool returns a sink that writes records to a temporary file, which is copied to out when the sink is closed.
+func spool(out io.Writer, format string, columns []string) (Sink, error) {
+	if !export.IsFormat(format) {
+		return nil, errors.Wrapf(ErrBadParameter, "unknown export format %q", format)
+	}
+
+	file, err := ioutil.TempFile("", "sink")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	spooled := &spooledFile{File: file, out: out}
+
+	w, err := newExportWriter(format, columns, file)
+	if err != nil {
+		spooled.Abort()
+		return nil, err
+	}
+	return NewWriter(w, spooled)
+}
+
+// File returns a sink that writes records to a file in an export format, replacing the file. The records are
+// written to <path>.tmp, which replaces the file when the sink is closed, so an aborted sink leaves it as it was.
+func File(path, format string, columns ...string) (Sink, error) {
+	if !export.IsFormat(format) {
+		return nil, errors.Wrapf(ErrBadParameter, "unknown export format %q", format)
+	}
+
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	pending := &pendingFile{File: file, path: path}
+
+	w, err := newExportWriter(format, columns, file)
+	if err != nil {
+		pending.Abort()
+		return nil, err
+	}
+	return NewWriter(w, pending)
+}
+
+// pendingFile is a temporary file that replaces the file at path when it is closed.
+type pendingFile struct {
+	*os.File
+	path string
+}
+
+func (f *pendingFile) Close() error {
+	if err := f.File.Close(); err != nil {
+		os.Remove(f.Name())
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(f.Name(), f.path))
+}
+
+// Abort closes and removes the temporary file.
+func (f *pendingFile) Abort() error {
+	err := f.File.Close()
+	if removeErr := os.Remove(f.Name()); err == nil {
+		err = removeErr
+	}
+	return errors.WithStack(err)
+}
+
+// spooledFile is a temporary file that is copied to out, and removed, when it is closed.
+type spooledFile struct {
+	*os.File
+	out io.Writer
+}
+
+func (f *spooledFile) Close() error {
+	_, err := f.Seek(0, io.SeekStart)
+	if err == nil {
+		_, err = io.Copy(f.out, f.File)
+	}
+	if abortErr := f.Abort(); err == nil {
+		err = abortErr
+	}
+	return errors.WithStack(err)
+}
+
+// Abort closes and removes the temporary file.
+func (f *spooledFile) Abort() error {
+	err := f.File.Close()
+	if removeErr := os.Remove(f.Name()); err == nil {
+		err = removeErr
+	}
+	return errors.WithStack(err)
+}
+
+func newExportWriter(format string, columns []string, output io.Writer) (export.Writer, error) {
+	if format == export.FormatCSV {
+		w, err := export.NewCSVWriter(output, columns...)
+		if err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+	return export.NewWriter(format, output)
+}
+
+func (s *writerSink) Write(ctx context.Context, r *product.Record) error {
+	if err := ctx.Err(); err != nil {
+		return errors.WithStack(err)
+	}
+	return s.w.Write(r)
+}
+
+func (s *writerSink) Flush() error {
+	return s.w.Flush()
+}
+
+func (s *writerSink) Close() error {
+	err := s.w.Flush()
+	if s.closer != nil {
+		if closeErr := s.closer.Close(); err == nil {
+			err = errors.WithStack(closeErr)
+		}
+	}
+	return err
+}
+
+// Abort closes the sink without flushing it. A closer that can be aborted, such as a file sink's file, is aborted.
+func (s *writerSink) Abort() error {
+	if s.closer == nil {
+		return nil
+	}
+	if a, ok := s.closer.(interface{ Abort() error }); ok {
+		return a.Abort()
+	}
+	return errors.WithStack(s.closer.Close())
+}
@@ -0,0 +1,127 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/export"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type writerTestSuite struct {
+	suite.Suite
+	dir string
+}
+
+func Test_Writer(t *testing.T) {
+	s := new(writerTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *writerTestSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "sink")
+	require.NoError(s.T(), err)
+}
+
+func (s *writerTestSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *writerTestSuite) Test_NewWriter_NilWriter_ReturnsError() {
+	_, err := NewWriter(nil, nil)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *writerTestSuite) Test_NewWriter_WritesWithExportWriter() {
+	var output bytes.Buffer
+	w, _ := export.NewWriter(export.FormatText, &output)
+	sink, err := NewWriter(w, nil)
+	require.NoError(s.T(), err)
+
+	r := record(1, 100)
+	require.NoError(s.T(), sink.Write(context.Background(), r))
+	require.NoError(s.T(), sink.Close())
+	require.Equal(s.T(), r.String()+"\n", output.String())
+}
+
+func (s *writerTestSuite) Test_Write_CanceledContext_ReturnsError() {
+	w, _ := export.NewWriter(export.FormatText, &bytes.Buffer{})
+	sink, _ := NewWriter(w, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := sink.Write(ctx, record(1, 100))
+	require.Equal(s.T(), context.Canceled, errors.Cause(err))
+}
+
+func (s *writerTestSuite) Test_Spool_WritesOutputOnClose() {
+	var output bytes.Buffer
+	sink, err := spool(&output, export.FormatCSV, []string{"id", "price"})
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), sink.Write(context.Background(), record(1, 100)))
+	require.NoError(s.T(), sink.Flush())
+	require.Empty(s.T(), output.String())
+
+	require.NoError(s.T(), sink.Close())
+	require.Equal(s.T(), "id,price\n1,1\n", output.String())
+}
+
+func (s *writerTestSuite) Test_Spool_Abort_WritesNothing() {
+	var output bytes.Buffer
+	sink, err := spool(&output, export.FormatText, nil)
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), sink.Write(context.Background(), record(1, 100)))
+	require.NoError(s.T(), sink.Flush())
+	require.NoError(s.T(), sink.Abort())
+	require.Empty(s.T(), output.String())
+}
+
+func (s *writerTestSuite) Test_File_CSV_WritesFileOnClose() {
+	path := filepath.Join(s.dir, "catalog.csv")
+	sink, err := File(path, export.FormatCSV, "id", "price")
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), sink.Write(context.Background(), record(1, 100)))
+	require.NoError(s.T(), sink.Close())
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "id,price\n1,1\n", string(data))
+}
+
+func (s *writerTestSuite) Test_File_UnknownFormat_DoesNotCreateFile() {
+	path := filepath.Join(s.dir, "catalog.xml")
+	_, err := File(path, "xml")
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+
+	_, err = os.Stat(path)
+	require.True(s.T(), os.IsNotExist(err))
+}
+
+func (s *writerTestSuite) Test_File_Abort_LeavesFileAsItWas() {
+	path := filepath.Join(s.dir, "catalog.csv")
+	require.NoError(s.T(), ioutil.WriteFile(path, []byte("id\n1\n"), 0644))
+
+	sink, err := File(path, export.FormatCSV, "id")
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), sink.Write(context.Background(), record(2, 100)))
+	require.NoError(s.T(), sink.Flush())
+	require.NoError(s.T(), sink.Abort())
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "id\n1\n", string(data))
+
+	_, err = os.Stat(path + ".tmp")
+	require.True(s.T(), os.IsNotExist(err))
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// ErrOutOfOrder is the error returned when a product is upserted as seen before its current version.
+	ErrOutOfOrder = errors.New("Product seen before its current version")
+
+	// historyBucket holds a bucket of Versions for each product, keyed by ID, with the versions keyed by ValidFrom.
+	historyBucket = []byte("history")
+)
+
+// Version is a product's record while it was unchanged.
+type Version struct {
+	Record *product.Record `json:"record"`
+
+	// Source is the file the version was first seen in.
+	Source string `json:"source"`
+
+	// ValidFrom is when the version was first seen, and ValidTo when it was replaced. ValidTo is zero for the
+	// current version.
+	ValidFrom time.Time `json:"valid_from"`
+	ValidTo   time.Time `json:"valid_to"`
+}
+
+// IsCurrent returns true if the version hasn't been replaced.
+func (v Version) IsCurrent() bool {
+	return v.ValidTo.IsZero()
+}
+
+// changed returns true if the records differ in any field, as they are stored.
+func changed(a, b *product.Record) (bool, error) {
+	aData, err := json.Marshal(a)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	bData, err := json.Marshal(b)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return !bytes.Equal(aData, bData), nil
+}
+
+// addVersion starts a new version of a product if the record changes it, ending the current version.
+func addVersion(tx *bolt.Tx, r *product.Record, source string, seen time.Time) error {
+	history, err := tx.Bucket(historyBucket).CreateBucketIfNotExists(key(r.ID))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	k, data := history.Cursor().Last()
+	if k != nil {
+		var current Version
+		if err := json.Unmarshal(data, &current); err != nil {
+			return errors.Wrapf(err, "reading product %d history", r.ID)
+		}
+		if seen.Before(current.ValidFrom) {
+			return errors.Wrapf(ErrOutOfOrder, "product %d seen at %s, current version is from %s",
+				r.ID, seen.Format(time.RFC3339), current.ValidFrom.Format(time.RFC3339))
+		}
+		if diff, err := changed(current.Record, r); err != nil || !diff {
+			return err
+		}
+
+		// A version replaced the moment it started never applied, so it is overwritten.
+		if seen.After(current.ValidFrom) {
+			current.ValidTo = seen
+			if err := putVersion(history, k, current); err != nil {
+				return err
+			}
+		}
+	}
+
+	return putVersion(history, timeKey(seen), Version{Record: r, Source: source, ValidFrom: seen})
+}
+
+func putVersion(history *bolt.Bucket, k []byte, v Version) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(history.Put(k, data))
+}
+
+// History returns every version of a product, oldest first.
+func (s *Store) History(id int) ([]Version, error) {
+	var versions []Version
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		history := tx.Bucket(historyBucket).Bucket(key(id))
+		if history == nil {
+			return errors.Wrapf(ErrNotFound, "product %d", id)
+		}
+		return history.ForEach(func(k, data []byte) error {
+			var v Version
+			if err := json.Unmarshal(data, &v); err != nil {
+				return errors.WithStack(err)
+			}
+			versions = append(versions, v)
+			return nil
+		})
+	})
+
+	return versions, err
+}
+
+// AsOf returns the version of a product that was valid at a point in time.
+func (s *Store) AsOf(id int, at time.Time) (Version, error) {
+	var v Version
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		history := tx.Bucket(historyBucket).Bucket(key(id))
+		if history == nil {
+			return errors.Wrapf(ErrNotFound, "product %d", id)
+		}
+
+		// Find the last version that started at or before the time.
+		c := history.Cursor()
+		k, data := c.Seek(timeKey(at))
+		switch {
+		case k == nil:
+			k, data = c.Last()
+		case !bytes.Equal(k, timeKey(at)):
+			k, data = c.Prev()
+		}
+		if k == nil {
+			return errors.Wrapf(ErrNotFound, "product %d as of %s", id, at.Format(time.RFC3339))
+		}
+
+		if err := json.Unmarshal(data, &v); err != nil {
+			return errors.WithStack(err)
+		}
+		if !v.IsCurrent() && !at.Before(v.ValidTo) {
+			return errors.Wrapf(ErrNotFound, "product %d as of %s", id, at.Format(time.RFC3339))
+		}
+		return nil
+	})
+
+	return v, err
+}
+
+// timeKey returns the database key of a version's start time. Keys sort in time order.
+func timeKey(t time.Time) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(t.UnixNano())^(1<<63))
+	return k
+}
@@ -0,0 +1,194 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type historyTestSuite struct {
+	suite.Suite
+	dir    string
+	store  *Store
+	monday time.Time
+}
+
+func Test_History(t *testing.T) {
+	s := new(historyTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *historyTestSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "history")
+	require.NoError(s.T(), err)
+
+	s.store, err = Open(filepath.Join(s.dir, "catalog.db"))
+	require.NoError(s.T(), err)
+
+	s.monday = time.Date(2026, 10, 5, 2, 0, 0, 0, time.UTC)
+}
+
+func (s *historyTestSuite) TearDownTest() {
+	s.store.Close()
+	os.RemoveAll(s.dir)
+}
+
+func (s *historyTestSuite) day(n int) time.Time {
+	return s.monday.Add(time.Duration(n) * 24 * time.Hour)
+}
+
+func (s *historyTestSuite) Test_Upsert_PriceChange_AddsVersion() {
+	t := s.T()
+
+	require.NoError(t, s.store.Upsert("monday.txt", s.day(0), record(14963801, 650)))
+	require.NoError(t, s.store.Upsert("tuesday.txt", s.day(1), record(14963801, 650)))
+	require.NoError(t, s.store.Upsert("wednesday.txt", s.day(2), record(14963801, 700)))
+
+	versions, err := s.store.History(14963801)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	require.Equal(t, "monday.txt", versions[0].Source)
+	require.True(t, s.day(0).Equal(versions[0].ValidFrom))
+	require.True(t, s.day(2).Equal(versions[0].ValidTo))
+	require.False(t, versions[0].IsCurrent())
+
+	require.Equal(t, "wednesday.txt", versions[1].Source)
+	require.True(t, versions[1].Record.Price.Equal(decimal.New(7, 0)))
+	require.True(t, versions[1].IsCurrent())
+}
+
+func (s *historyTestSuite) Test_Upsert_DescriptionChange_AddsVersion() {
+	t := s.T()
+
+	require.NoError(t, s.store.Upsert("monday.txt", s.day(0), record(1, 650)))
+	changed := record(1, 650)
+	changed.Description = "Brown rice"
+	require.NoError(t, s.store.Upsert("tuesday.txt", s.day(1), changed))
+
+	versions, err := s.store.History(1)
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	v, err := s.store.AsOf(1, s.day(0))
+	require.NoError(t, err)
+	require.Equal(t, "Rice", v.Record.Description)
+
+	v, err = s.store.AsOf(1, s.day(1))
+	require.NoError(t, err)
+	require.Equal(t, "Brown rice", v.Record.Description)
+}
+
+func (s *historyTestSuite) Test_Upsert_Unchanged_KeepsVersion() {
+	require.NoError(s.T(), s.store.Upsert("monday.txt", s.day(0), record(1, 650)))
+	require.NoError(s.T(), s.store.Upsert("tuesday.txt", s.day(1), record(1, 650)))
+
+	versions, err := s.store.History(1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), versions, 1)
+}
+
+func (s *historyTestSuite) Test_Upsert_VersionedFields_AddVersions() {
+	t := s.T()
+
+	r := record(1, 650)
+	require.NoError(t, s.store.Upsert("a.txt", s.day(0), r))
+
+	promo := *r
+	promo.PromoPrice = decimal.New(5, 0)
+	require.NoError(t, s.store.Upsert("a.txt", s.day(1), &promo))
+
+	taxed := promo
+	taxed.TaxRate = decimal.New(7775, -5)
+	require.NoError(t, s.store.Upsert("a.txt", s.day(2), &taxed))
+
+	weighed := taxed
+	weighed.Unit = product.UnitPound
+	require.NoError(t, s.store.Upsert("a.txt", s.day(3), &weighed))
+
+	versions, err := s.store.History(1)
+	require.NoError(t, err)
+	require.Len(t, versions, 4)
+}
+
+func (s *historyTestSuite) Test_Upsert_SeenBeforeCurrentVersion_ReturnsError() {
+	require.NoError(s.T(), s.store.Upsert("tuesday.txt", s.day(1), record(1, 650)))
+
+	err := s.store.Upsert("monday.txt", s.day(0), record(1, 600))
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrOutOfOrder, errors.Cause(err))
+
+	// The failed upsert changes nothing.
+	e, _ := s.store.Get(1)
+	require.Equal(s.T(), "tuesday.txt", e.Source)
+}
+
+func (s *historyTestSuite) Test_Upsert_SeenBeforeLastSeen_ReturnsError() {
+	t := s.T()
+
+	require.NoError(t, s.store.Upsert("monday.txt", s.day(0), record(1, 650)))
+	require.NoError(t, s.store.Upsert("wednesday.txt", s.day(2), record(1, 650)))
+
+	err := s.store.Upsert("tuesday.txt", s.day(1), record(1, 600))
+	require.Error(t, err)
+	require.Equal(t, ErrOutOfOrder, errors.Cause(err))
+
+	e, _ := s.store.Get(1)
+	require.Equal(t, "wednesday.txt", e.Source)
+	require.True(t, s.day(2).Equal(e.LastSeen))
+	require.True(t, e.Record.Price.Equal(decimal.New(650, -2)))
+
+	versions, _ := s.store.History(1)
+	require.Len(t, versions, 1)
+}
+
+func (s *historyTestSuite) Test_Upsert_ChangedTwiceAtSameTime_KeepsLast() {
+	require.NoError(s.T(), s.store.Upsert("a.txt", s.day(0), record(1, 650), record(1, 700)))
+
+	versions, err := s.store.History(1)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), versions, 1)
+	require.True(s.T(), versions[0].Record.Price.Equal(decimal.New(7, 0)))
+}
+
+func (s *historyTestSuite) Test_AsOf_ReturnsVersionValidAtTime() {
+	t := s.T()
+
+	require.NoError(t, s.store.Upsert("monday.txt", s.day(0), record(1, 650)))
+	require.NoError(t, s.store.Upsert("wednesday.txt", s.day(2), record(1, 700)))
+	require.NoError(t, s.store.Upsert("friday.txt", s.day(4), record(1, 675)))
+
+	for at, cents := range map[time.Time]int64{
+		s.day(0):                    650,
+		s.day(1):                    650,
+		s.day(2).Add(-time.Second):  650,
+		s.day(2):                    700,
+		s.day(3):                    700,
+		s.day(4):                    675,
+		s.day(30):                   675,
+		s.day(4).Add(time.Hour * 1): 675,
+	} {
+		v, err := s.store.AsOf(1, at)
+		require.NoError(t, err, at.String())
+		require.True(t, v.Record.Price.Equal(decimal.New(cents, -2)), at.String())
+	}
+}
+
+func (s *historyTestSuite) Test_AsOf_BeforeFirstSeen_ReturnsNotFound() {
+	require.NoError(s.T(), s.store.Upsert("monday.txt", s.day(0), record(1, 650)))
+
+	_, err := s.store.AsOf(1, s.day(-1))
+	require.Equal(s.T(), ErrNotFound, errors.Cause(err))
+
+	_, err = s.store.AsOf(2, s.day(0))
+	require.Equal(s.T(), ErrNotFound, errors.Cause(err))
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// ErrBadParameter is the error returned when invalid input is provided.
+	ErrBadParameter = errors.New("Invalid parameter")
+
+	// ErrNotFound is the error returned when the store has no product with an ID.
+	ErrNotFound = errors.New("Product not found")
+
+	// productsBucket holds an Entry for each product, keyed by ID.
+	productsBucket = []byte("products")
+)
+
+// Entry is a product in the store, with when and where it was seen.
+type Entry struct {
+	Record *product.Record `json:"record"`
+
+	// Source is the file the product was last seen in.
+	Source string `json:"source"`
+
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Store is a persistent catalog of product records, kept in a single bbolt database file.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the store in the file at path, creating it if it doesn't exist. Only one process can have a store open.
+func Open(path string) (*Store, error) {
+	if path == "" {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening store %s", path)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{productsBucket, historyBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, errors.WithStack(err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close closes the store's database file.
+func (s *Store) Close() error {
+	return errors.WithStack(s.db.Close())
+}
+
+// Upsert adds or replaces the records of products seen in source at the given time, in a single transaction.
+// A product keeps the time it was first seen. A record that changes a product starts a new Version. A product can't
+// be upserted as seen before it was last seen, so that an older file never replaces a newer record.
+func (s *Store) Upsert(source string, seen time.Time, records ...*product.Record) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(productsBucket)
+
+		for _, r := range records {
+			if r == nil {
+				return errors.WithStack(ErrBadParameter)
+			}
+
+			entry := Entry{Record: r, Source: source, FirstSeen: seen, LastSeen: seen}
+
+			k := key(r.ID)
+			if data := b.Get(k); data != nil {
+				var existing Entry
+				if err := json.Unmarshal(data, &existing); err != nil {
+					return errors.Wrapf(err, "reading product %d", r.ID)
+				}
+				if seen.Before(existing.LastSeen) {
+					return errors.Wrapf(ErrOutOfOrder, "product %d seen at %s, last seen at %s",
+						r.ID, seen.Format(time.RFC3339), existing.LastSeen.Format(time.RFC3339))
+				}
+				entry.FirstSeen = existing.FirstSeen
+			}
+
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if err := b.Put(k, data); err != nil {
+				return errors.WithStack(err)
+			}
+
+			if err := addVersion(tx, r, source, seen); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Get returns the product with the given ID.
+func (s *Store) Get(id int) (Entry, error) {
+	var entry Entry
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(productsBucket).Get(key(id))
+		if data == nil {
+			return errors.Wrapf(ErrNotFound, "product %d", id)
+		}
+		return errors.WithStack(json.Unmarshal(data, &entry))
+	})
+
+	return entry, err
+}
+
+// ForEach calls fn with each product in the store, in ID order, stopping at the first error.
+func (s *Store) ForEach(fn func(Entry) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(productsBucket).ForEach(func(k, data []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return errors.WithStack(err)
+			}
+			return fn(entry)
+		})
+	})
+}
+
+// Count returns the number of products in the store.
+func (s *Store) Count() (int, error) {
+	var n int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(productsBucket).Stats().KeyN
+		return nil
+	})
+	return n, errors.WithStack(err)
+}
+
+// key returns the database key of a product ID. Keys sort in ID order, including negative IDs.
+func key(id int) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(int64(id))^(1<<63))
+	return k
+}
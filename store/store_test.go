@@ -0,0 +1,113 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type storeTestSuite struct {
+	suite.Suite
+	dir   string
+	store *Store
+}
+
+func Test_Store(t *testing.T) {
+	s := new(storeTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *storeTestSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "store")
+	require.NoError(s.T(), err)
+
+	s.store, err = Open(filepath.Join(s.dir, "catalog.db"))
+	require.NoError(s.T(), err)
+}
+
+func (s *storeTestSuite) TearDownTest() {
+	s.store.Close()
+	os.RemoveAll(s.dir)
+}
+
+func record(id int, cents int64) *product.Record {
+	return &product.Record{ID: id, Description: "Rice", Price: decimal.New(cents, -2), Unit: product.UnitEach}
+}
+
+func (s *storeTestSuite) Test_Get_Missing_ReturnsNotFound() {
+	_, err := s.store.Get(1)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrNotFound, errors.Cause(err))
+}
+
+func (s *storeTestSuite) Test_Upsert_New_GetReturnsEntry() {
+	seen := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+	require.NoError(s.T(), s.store.Upsert("monday.txt", seen, record(80000001, 567)))
+
+	e, err := s.store.Get(80000001)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 80000001, e.Record.ID)
+	require.True(s.T(), e.Record.Price.Equal(decimal.New(567, -2)))
+	require.Equal(s.T(), "monday.txt", e.Source)
+	require.True(s.T(), seen.Equal(e.FirstSeen))
+	require.True(s.T(), seen.Equal(e.LastSeen))
+}
+
+func (s *storeTestSuite) Test_Upsert_Existing_ReplacesRecordAndKeepsFirstSeen() {
+	monday := time.Date(2026, 10, 5, 2, 0, 0, 0, time.UTC)
+	tuesday := monday.Add(24 * time.Hour)
+
+	require.NoError(s.T(), s.store.Upsert("monday.txt", monday, record(1, 567)))
+	require.NoError(s.T(), s.store.Upsert("tuesday.txt", tuesday, record(1, 599), record(2, 100)))
+
+	e, err := s.store.Get(1)
+	require.NoError(s.T(), err)
+	require.True(s.T(), e.Record.Price.Equal(decimal.New(599, -2)))
+	require.Equal(s.T(), "tuesday.txt", e.Source)
+	require.True(s.T(), monday.Equal(e.FirstSeen))
+	require.True(s.T(), tuesday.Equal(e.LastSeen))
+
+	n, err := s.store.Count()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, n)
+}
+
+func (s *storeTestSuite) Test_Upsert_NilRecord_WritesNothing() {
+	err := s.store.Upsert("monday.txt", time.Now(), record(1, 567), nil)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+
+	n, _ := s.store.Count()
+	require.Equal(s.T(), 0, n)
+}
+
+func (s *storeTestSuite) Test_ForEach_ReturnsEntriesInIDOrder() {
+	require.NoError(s.T(), s.store.Upsert("a.txt", time.Now(), record(30, 1), record(-5, 1), record(2, 1)))
+
+	var ids []int
+	require.NoError(s.T(), s.store.ForEach(func(e Entry) error {
+		ids = append(ids, e.Record.ID)
+		return nil
+	}))
+	require.Equal(s.T(), []int{-5, 2, 30}, ids)
+}
+
+func (s *storeTestSuite) Test_Open_Reopened_KeepsProducts() {
+	require.NoError(s.T(), s.store.Upsert("a.txt", time.Now(), record(1, 567)))
+	require.NoError(s.T(), s.store.Close())
+
+	var err error
+	s.store, err = Open(filepath.Join(s.dir, "catalog.db"))
+	require.NoError(s.T(), err)
+
+	_, err = s.store.Get(1)
+	require.NoError(s.T(), err)
+}
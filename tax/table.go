@@ -0,0 +1,293 @@
+package tax
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Table formats accepted by ReadTable.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// DateFormat is the format of the effective and expiry dates in a table file.
+const DateFormat = "2006-01-02"
+
+var (
+	// ErrBadParameter is the error returned when invalid input is provided.
+	ErrBadParameter = errors.New("Invalid parameter")
+
+	// ErrBadTable is the error returned when a tax table is invalid.
+	ErrBadTable = errors.New("Invalid tax table")
+
+	// ErrNoRate is the error returned when no rule gives the tax rate of a product.
+	ErrNoRate = errors.New("No tax rate")
+)
+
+// Rule is the tax rate of the taxable products matching its store, region and category, between its effective
+// and expiry dates. An empty store, region or category matches any value.
+type Rule struct {
+	Store  string
+	Region string
+
+	// Category is the name of a product flag, such as "food_stamp", that the product must have.
+	Category string
+
+	Rate decimal.Decimal
+
+	// Effective is the first day the rule applies, and Expires the first day it no longer applies.
+	// A zero date leaves the rule unbounded.
+	Effective time.Time
+	Expires   time.Time
+}
+
+// Table is a set of tax rules. The most specific rule matching a product gives its rate: a store match outranks
+// a region match, which outranks a category match. Of equally specific rules, the latest effective rule wins, then
+// the earliest in the table.
+type Table struct {
+	Rules []Rule
+}
+
+// tableFile is a table as written in a JSON or YAML file.
+type tableFile struct {
+	Rules []ruleFile `json:"rules" yaml:"rules"`
+}
+
+// ruleFile is a rule as written in a JSON or YAML file. The rate is read as text to keep it exact.
+type ruleFile struct {
+	Store     string      `json:"store" yaml:"store"`
+	Region    string      `json:"region" yaml:"region"`
+	Category  string      `json:"category" yaml:"category"`
+	Rate      json.Number `json:"rate" yaml:"rate"`
+	Effective string      `json:"effective" yaml:"effective"`
+	Expires   string      `json:"expires" yaml:"expires"`
+}
+
+// LoadTable reads a table from a JSON or YAML file, chosen by the file extension.
+func LoadTable(filename string) (*Table, error) {
+	var format string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		format = FormatJSON
+	case ".yaml", ".yml":
+		format = FormatYAML
+	default:
+		return nil, errors.Wrapf(ErrBadTable, "unknown tax table file type %s", filename)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return ReadTable(bytes.NewReader(data), format)
+}
+
+// ReadTable decodes and validates a table in the given format (FormatJSON or FormatYAML).
+func ReadTable(r io.Reader, format string) (*Table, error) {
+	if r == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var f tableFile
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&f)
+	case FormatYAML:
+		err = yaml.UnmarshalStrict(data, &f)
+	default:
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+	if err != nil {
+		return nil, errors.Wrap(ErrBadTable, err.Error())
+	}
+
+	t := &Table{Rules: make([]Rule, 0, len(f.Rules))}
+	for i, rf := range f.Rules {
+		rule, err := rf.rule()
+		if err != nil {
+			return nil, errors.Wrapf(err, "rule %d", i+1)
+		}
+		t.Rules = append(t.Rules, rule)
+	}
+
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// rule converts the text of a rule.
+func (rf ruleFile) rule() (Rule, error) {
+	rate, err := decimal.NewFromString(rf.Rate.String())
+	if err != nil {
+		return Rule{}, errors.Wrapf(ErrBadTable, "bad rate %q", rf.Rate)
+	}
+
+	effective, err := parseDate(rf.Effective)
+	if err != nil {
+		return Rule{}, err
+	}
+	expires, err := parseDate(rf.Expires)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	return Rule{
+		Store:     rf.Store,
+		Region:    rf.Region,
+		Category:  rf.Category,
+		Rate:      rate,
+		Effective: effective,
+		Expires:   expires,
+	}, nil
+}
+
+// parseDate parses a date in DateFormat, or returns the zero time for an empty date.
+func parseDate(text string) (time.Time, error) {
+	if text == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(DateFormat, text)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(ErrBadTable, "bad date %q", text)
+	}
+	return date, nil
+}
+
+// Validate checks that the rule rates are fractions, their categories are known flags, their dates are in order,
+// and no two rules apply to the same products from the same date.
+func (t *Table) Validate() error {
+	type key struct {
+		store, region, category string
+		effective               time.Time
+	}
+	seen := make(map[key]bool)
+
+	for i, r := range t.Rules {
+		if r.Rate.IsNegative() || r.Rate.GreaterThanOrEqual(decimal.New(1, 0)) {
+			return errors.Wrapf(ErrBadTable, "rule %d rate %s must be at least 0 and less than 1", i+1, r.Rate)
+		}
+		if r.Category != "" {
+			if _, err := product.ParseFlag(r.Category); err != nil {
+				return errors.Wrapf(ErrBadTable, "rule %d has unknown category %q", i+1, r.Category)
+			}
+		}
+		if !r.Expires.IsZero() && !r.Expires.After(r.Effective) {
+			return errors.Wrapf(ErrBadTable, "rule %d expires before it is effective", i+1)
+		}
+
+		k := key{r.Store, r.Region, r.Category, r.Effective}
+		if seen[k] {
+			return errors.Wrapf(ErrBadTable, "rule %d duplicates an earlier rule", i+1)
+		}
+		seen[k] = true
+	}
+
+	return nil
+}
+
+// Rate returns the tax rate of a taxable product with the given flags, sold by a store in a region on a date.
+// Only the calendar day of the date, in its own location, is used.
+func (t *Table) Rate(store, region string, flags product.Flags, date time.Time) (decimal.Decimal, error) {
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+
+	best := -1
+	bestScore := -1
+
+	for i, r := range t.Rules {
+		score, ok := r.match(store, region, flags, date)
+		if !ok {
+			continue
+		}
+		if score > bestScore || (score == bestScore && r.Effective.After(t.Rules[best].Effective)) {
+			best, bestScore = i, score
+		}
+	}
+
+	if best < 0 {
+		return decimal.Zero, errors.Wrapf(ErrNoRate, "store %q region %q flags %v on %s", store, region, flags, date.Format(DateFormat))
+	}
+	return t.Rules[best].Rate, nil
+}
+
+// match returns true and the specificity of the rule if it applies to the product.
+func (r Rule) match(store, region string, flags product.Flags, date time.Time) (int, bool) {
+	score := 0
+
+	if r.Store != "" {
+		if r.Store != store {
+			return 0, false
+		}
+		score += 4
+	}
+	if r.Region != "" {
+		if r.Region != region {
+			return 0, false
+		}
+		score += 2
+	}
+	if r.Category != "" {
+		flag, err := product.ParseFlag(r.Category)
+		if err != nil || !flags.Has(flag) {
+			return 0, false
+		}
+		score++
+	}
+
+	if date.Before(r.Effective) || (!r.Expires.IsZero() && !date.Before(r.Expires)) {
+		return 0, false
+	}
+
+	return score, true
+}
+
+// Policy returns the tax policy of a store in a region, for products sold on the given date.
+func (t *Table) Policy(store, region string, date time.Time) Policy {
+	return Policy{table: t, store: store, region: region, date: date}
+}
+
+// Policy provides the tax rates of a single store's products from a Table.
+type Policy struct {
+	table  *Table
+	store  string
+	region string
+	date   time.Time
+}
+
+// TaxRate returns the tax rate of a taxable product with the given flags.
+func (p Policy) TaxRate(flags product.Flags) (decimal.Decimal, error) {
+	if p.table == nil {
+		return decimal.Zero, errors.WithStack(ErrNoRate)
+	}
+	return p.table.Rate(p.store, p.region, flags, p.date)
+}
+
+// Fixed is a tax policy with a single rate for every taxable product.
+type Fixed struct {
+	Rate decimal.Decimal
+}
+
+// TaxRate returns the fixed rate.
+func (f Fixed) TaxRate(flags product.Flags) (decimal.Decimal, error) {
+	return f.Rate, nil
+}
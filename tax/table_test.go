@@ -0,0 +1,149 @@
+package tax
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/product"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type tableTestSuite struct {
+	suite.Suite
+	table *Table
+}
+
+func Test_Table(t *testing.T) {
+	s := new(tableTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *tableTestSuite) SetupTest() {
+	var err error
+	s.table, err = LoadTable("../cmd/ingester/tax-sample.yaml")
+	require.NoError(s.T(), err)
+}
+
+func date(text string) time.Time {
+	d, _ := time.Parse(DateFormat, text)
+	return d
+}
+
+func (s *tableTestSuite) requireRate(expected string, store, region string, flags product.Flags, on string) {
+	rate, err := s.table.Rate(store, region, flags, date(on))
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), expected, rate.String())
+}
+
+func (s *tableTestSuite) Test_LoadTable_SampleFile_ReadsExactRates() {
+	require.Len(s.T(), s.table.Rules, 5)
+	require.True(s.T(), s.table.Rules[1].Rate.Equal(decimal.New(7775, -5)))
+	require.Equal(s.T(), date("2019-01-01"), s.table.Rules[1].Effective)
+}
+
+func (s *tableTestSuite) Test_LoadTable_UnknownExtension_ReturnsError() {
+	_, err := LoadTable("rates.txt")
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadTable, errors.Cause(err))
+}
+
+func (s *tableTestSuite) Test_Rate_Region_ReturnsEffectiveRate() {
+	s.requireRate("0.065", "0001", "WA", product.FlagTaxable, "2018-12-31")
+	s.requireRate("0.07775", "0001", "WA", product.FlagTaxable, "2019-01-01")
+}
+
+func (s *tableTestSuite) Test_Rate_Category_OutranksRegion() {
+	s.requireRate("0", "0001", "WA", product.FlagTaxable|product.FlagFoodStamp, "2020-06-01")
+}
+
+func (s *tableTestSuite) Test_Rate_Store_OutranksCategory() {
+	s.requireRate("0.101", "0042", "WA", product.FlagTaxable|product.FlagFoodStamp, "2020-06-01")
+}
+
+func (s *tableTestSuite) Test_Rate_NoMatchingRule_ReturnsError() {
+	_, err := s.table.Rate("0001", "CA", product.FlagTaxable, date("2020-06-01"))
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrNoRate, errors.Cause(err))
+}
+
+func (s *tableTestSuite) Test_Rate_Expired_IsNotUsed() {
+	t := &Table{Rules: []Rule{
+		{Rate: decimal.New(5, -2)},
+		{Rate: decimal.New(6, -2), Effective: date("2020-01-01"), Expires: date("2020-02-01")},
+	}}
+
+	rate, _ := t.Rate("", "", product.FlagTaxable, date("2020-01-31"))
+	require.Equal(s.T(), "0.06", rate.String())
+
+	rate, _ = t.Rate("", "", product.FlagTaxable, date("2020-02-01"))
+	require.Equal(s.T(), "0.05", rate.String())
+}
+
+func (s *tableTestSuite) Test_Rate_LocalTime_UsesCalendarDay() {
+	t := &Table{Rules: []Rule{{Rate: decimal.New(6, -2), Effective: date("2020-01-01")}}}
+
+	zone := time.FixedZone("UTC-8", -8*60*60)
+	_, err := t.Rate("", "", product.FlagTaxable, time.Date(2020, 1, 1, 1, 0, 0, 0, zone))
+	require.NoError(s.T(), err)
+}
+
+func (s *tableTestSuite) Test_Policy_TaxRate_UsesStoreRegionAndDate() {
+	p := s.table.Policy("0001", "WA", date("2019-06-01"))
+	rate, err := p.TaxRate(product.FlagTaxable)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "0.07775", rate.String())
+}
+
+func (s *tableTestSuite) Test_ReadTable_JSON_ReadsStringAndNumberRates() {
+	t, err := ReadTable(strings.NewReader(`{
+		"rules": [
+			{"region": "WA", "rate": 0.065},
+			{"region": "OR", "rate": "0.0001"}
+		]
+	}`), FormatJSON)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "0.065", t.Rules[0].Rate.String())
+	require.Equal(s.T(), "0.0001", t.Rules[1].Rate.String())
+}
+
+func (s *tableTestSuite) Test_ReadTable_UnknownField_ReturnsError() {
+	_, err := ReadTable(strings.NewReader("rules:\n  - {county: King, rate: 0.1}\n"), FormatYAML)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadTable, errors.Cause(err))
+}
+
+func (s *tableTestSuite) Test_ReadTable_BadDate_ReturnsError() {
+	_, err := ReadTable(strings.NewReader("rules:\n  - {rate: 0.1, effective: 01/01/2020}\n"), FormatYAML)
+	require.Error(s.T(), err)
+	require.Equal(s.T(), ErrBadTable, errors.Cause(err))
+}
+
+func (s *tableTestSuite) Test_Validate_BadRate_ReturnsError() {
+	t := &Table{Rules: []Rule{{Rate: decimal.New(1, 0)}}}
+	require.Equal(s.T(), ErrBadTable, errors.Cause(t.Validate()))
+}
+
+func (s *tableTestSuite) Test_Validate_UnknownCategory_ReturnsError() {
+	t := &Table{Rules: []Rule{{Category: "perishable", Rate: decimal.New(1, -1)}}}
+	require.Equal(s.T(), ErrBadTable, errors.Cause(t.Validate()))
+}
+
+func (s *tableTestSuite) Test_Validate_ExpiresBeforeEffective_ReturnsError() {
+	t := &Table{Rules: []Rule{{Rate: decimal.New(1, -1), Effective: date("2020-01-01"), Expires: date("2019-01-01")}}}
+	require.Equal(s.T(), ErrBadTable, errors.Cause(t.Validate()))
+}
+
+func (s *tableTestSuite) Test_Validate_DuplicateRule_ReturnsError() {
+	t := &Table{Rules: []Rule{{Region: "WA", Rate: decimal.New(1, -1)}, {Region: "WA", Rate: decimal.New(2, -1)}}}
+	require.Equal(s.T(), ErrBadTable, errors.Cause(t.Validate()))
+}
+
+func (s *tableTestSuite) Test_Fixed_TaxRate_ReturnsRate() {
+	rate, err := Fixed{Rate: decimal.New(7775, -5)}.TaxRate(product.FlagTaxable)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "0.07775", rate.String())
+}
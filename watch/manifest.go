@@ -0,0 +1,87 @@
+package watch
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/pkg/errors"
+)
+
+// Outcome is what became of a file taken from the inbox.
+type Outcome string
+
+const (
+	// OutcomeProcessed is the outcome of a file ingested successfully, which is moved to the processed folder.
+	OutcomeProcessed Outcome = "processed"
+
+	// OutcomeFailed is the outcome of a file that failed to ingest, which is moved to the failed folder.
+	OutcomeFailed Outcome = "failed"
+
+	// OutcomeDuplicate is the outcome of a file with the same content as one already processed. It isn't ingested
+	// again, and is moved to the processed folder.
+	OutcomeDuplicate Outcome = "duplicate"
+)
+
+// Entry is the manifest record of a file taken from the inbox.
+type Entry struct {
+	// File is the name of the file in the inbox, and Path is where it was moved to.
+	File string `json:"file"`
+	Path string `json:"path"`
+
+	SHA256   string        `json:"sha256"`
+	Size     int64         `json:"size"`
+	Outcome  Outcome       `json:"outcome"`
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	Stats    *parser.Stats `json:"stats,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// ReadManifest reads the entries of a manifest file. A missing file has no entries.
+func ReadManifest(filename string) ([]Entry, error) {
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, errors.Wrapf(err, "manifest %s line %d", filename, line)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return entries, nil
+}
+
+// appendEntry adds an entry to the end of a manifest file, syncing it to disk.
+func appendEntry(filename string, e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(file.Sync())
+}
@@ -0,0 +1,63 @@
+package watch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type manifestTestSuite struct {
+	suite.Suite
+	dir string
+}
+
+func Test_Manifest(t *testing.T) {
+	s := new(manifestTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *manifestTestSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "manifest")
+	require.NoError(s.T(), err)
+}
+
+func (s *manifestTestSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *manifestTestSuite) Test_ReadManifest_Missing_ReturnsNoEntries() {
+	entries, err := ReadManifest(filepath.Join(s.dir, ManifestFile))
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), entries)
+}
+
+func (s *manifestTestSuite) Test_AppendEntry_ReadManifest_ReturnsEntriesInOrder() {
+	filename := filepath.Join(s.dir, ManifestFile)
+	started := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+
+	require.NoError(s.T(), appendEntry(filename, Entry{File: "monday.txt", Outcome: OutcomeProcessed, Started: started, Stats: &parser.Stats{Records: 4}}))
+	require.NoError(s.T(), appendEntry(filename, Entry{File: "tuesday.txt", Outcome: OutcomeFailed, Error: "too many errors"}))
+
+	entries, err := ReadManifest(filename)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), entries, 2)
+	require.Equal(s.T(), "monday.txt", entries[0].File)
+	require.True(s.T(), started.Equal(entries[0].Started))
+	require.Equal(s.T(), 4, entries[0].Stats.Records)
+	require.Equal(s.T(), OutcomeFailed, entries[1].Outcome)
+}
+
+func (s *manifestTestSuite) Test_ReadManifest_BadLine_ReturnsError() {
+	filename := filepath.Join(s.dir, ManifestFile)
+	require.NoError(s.T(), ioutil.WriteFile(filename, []byte("{not json\n"), 0644))
+
+	_, err := ReadManifest(filename)
+	require.Error(s.T(), err)
+}
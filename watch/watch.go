@@ -0,0 +1,280 @@
+package watch
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ProcessedDir is the folder of the inbox that successfully ingested files are moved to.
+	ProcessedDir = "processed"
+
+	// FailedDir is the folder of the inbox that files which failed to ingest are moved to.
+	FailedDir = "failed"
+
+	// ManifestFile is the default name of the manifest in the inbox.
+	ManifestFile = "manifest.jsonl"
+
+	// DefaultInterval is the default time between scans of the inbox.
+	DefaultInterval = 5 * time.Second
+)
+
+// ErrBadParameter is the error returned when invalid input is provided.
+var ErrBadParameter = errors.New("Invalid parameter")
+
+// IngestFunc ingests a file, returning the stats of the parse run and an error if the run failed.
+type IngestFunc func(ctx context.Context, filename string) (parser.Stats, error)
+
+// Option configures optional watcher behavior.
+type Option func(*Watcher) error
+
+// WithInterval configures the time between scans of the inbox, instead of DefaultInterval.
+func WithInterval(d time.Duration) Option {
+	return func(w *Watcher) error {
+		if d <= 0 {
+			return errors.WithStack(ErrBadParameter)
+		}
+		w.interval = d
+		return nil
+	}
+}
+
+// WithManifest configures the file the manifest is kept in, instead of ManifestFile in the inbox.
+func WithManifest(filename string) Option {
+	return func(w *Watcher) error {
+		if filename == "" {
+			return errors.WithStack(ErrBadParameter)
+		}
+		w.manifest = filename
+		return nil
+	}
+}
+
+// fileState is how a file in the inbox looked when it was last scanned.
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watcher ingests the files that arrive in an inbox directory. A file is taken once it is complete: unchanged in
+// size and modification time between two scans. Files whose names start with "." are ignored, so uploads can be
+// written under a hidden name and renamed when complete.
+//
+// Each file is ingested once, then moved to the processed or failed folder of the inbox, and an Entry recording it
+// is appended to the manifest. A file with the same content as one processed before isn't ingested again, but one
+// with the content of a failed file is, so that a run that failed for a passing reason can be retried.
+type Watcher struct {
+	dir      string
+	ingest   IngestFunc
+	interval time.Duration
+	manifest string
+
+	// seen is the state of each file at the last scan, and processed the hashes of the file contents processed.
+	seen      map[string]fileState
+	processed map[string]bool
+}
+
+// New creates a watcher of the inbox dir, creating its processed and failed folders.
+func New(dir string, ingest IngestFunc, opts ...Option) (*Watcher, error) {
+	if dir == "" || ingest == nil {
+		return nil, errors.WithStack(ErrBadParameter)
+	}
+
+	w := &Watcher{
+		dir:       dir,
+		ingest:    ingest,
+		interval:  DefaultInterval,
+		manifest:  filepath.Join(dir, ManifestFile),
+		seen:      make(map[string]fileState),
+		processed: make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		if opt == nil {
+			return nil, errors.WithStack(ErrBadParameter)
+		}
+		if err := opt(w); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sub := range []string{ProcessedDir, FailedDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	entries, err := ReadManifest(w.manifest)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Outcome == OutcomeProcessed {
+			w.processed[e.SHA256] = true
+		}
+	}
+
+	return w, nil
+}
+
+// Run scans the inbox every interval until ctx is cancelled. Errors are logged, and scanning continues.
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Poll(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Error watching %s: %v", w.dir, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll scans the inbox once, taking each file that hasn't changed since the last scan, in name order.
+func (w *Watcher) Poll(ctx context.Context) error {
+	infos, err := ioutil.ReadDir(w.dir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	manifest, _ := filepath.Abs(w.manifest)
+	seen := make(map[string]fileState, len(infos))
+	var complete []string
+
+	for _, info := range infos {
+		name := info.Name()
+		if !info.Mode().IsRegular() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if path, _ := filepath.Abs(filepath.Join(w.dir, name)); path == manifest {
+			continue
+		}
+
+		state := fileState{size: info.Size(), modTime: info.ModTime()}
+		if last, ok := w.seen[name]; ok && last == state {
+			complete = append(complete, name)
+		} else {
+			seen[name] = state
+		}
+	}
+	w.seen = seen
+
+	sort.Strings(complete)
+	for _, name := range complete {
+		if err := ctx.Err(); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := w.take(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// take ingests a complete file, records it in the manifest and moves it out of the inbox.
+func (w *Watcher) take(ctx context.Context, name string) error {
+	path := filepath.Join(w.dir, name)
+	e := Entry{File: name, Started: time.Now()}
+
+	var err error
+	e.SHA256, e.Size, err = hashFile(path)
+	if err != nil {
+		return err
+	}
+
+	if w.processed[e.SHA256] {
+		e.Outcome = OutcomeDuplicate
+		return w.record(e)
+	}
+
+	stats, err := w.ingest(ctx, path)
+	if ctx.Err() != nil {
+		// Interrupted runs leave the file to be ingested again.
+		return errors.WithStack(ctx.Err())
+	}
+
+	e.Stats = &stats
+	e.Outcome = OutcomeProcessed
+	if err != nil {
+		e.Outcome = OutcomeFailed
+		e.Error = err.Error()
+	}
+
+	if err := w.record(e); err != nil {
+		return err
+	}
+	if e.Outcome == OutcomeProcessed {
+		w.processed[e.SHA256] = true
+	}
+	return nil
+}
+
+// record moves a file to the folder of its outcome, and then appends its entry to the manifest. A file that can't
+// be moved is left in the inbox, without an entry, to be taken again.
+func (w *Watcher) record(e Entry) error {
+	dir := ProcessedDir
+	if e.Outcome == OutcomeFailed {
+		dir = FailedDir
+	}
+
+	dest, err := freeName(filepath.Join(w.dir, dir), e.File)
+	if err != nil {
+		return err
+	}
+	e.Path = dest
+	e.Finished = time.Now()
+
+	if err := os.Rename(filepath.Join(w.dir, e.File), dest); err != nil {
+		return errors.WithStack(err)
+	}
+	return appendEntry(w.manifest, e)
+}
+
+// freeName returns a path in dir for a file named name that isn't in use, adding a ".N" suffix if needed.
+func freeName(dir, name string) (string, error) {
+	path := filepath.Join(dir, name)
+	for n := 1; ; n++ {
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		path = filepath.Join(dir, name+"."+strconv.Itoa(n))
+	}
+}
+
+// hashFile returns the SHA-256 hash and size of a file.
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, errors.WithStack(err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, file)
+	if err != nil {
+		return "", 0, errors.WithStack(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
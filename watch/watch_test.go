@@ -0,0 +1,227 @@
+package watch
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jessejohnston/ProductIngester/parser"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type watchTestSuite struct {
+	suite.Suite
+	dir      string
+	ingested []string
+	fail     map[string]bool
+	watcher  *Watcher
+}
+
+func Test_Watch(t *testing.T) {
+	s := new(watchTestSuite)
+	suite.Run(t, s)
+}
+
+func (s *watchTestSuite) SetupTest() {
+	var err error
+	s.dir, err = ioutil.TempDir("", "watch")
+	require.NoError(s.T(), err)
+
+	s.ingested = nil
+	s.fail = make(map[string]bool)
+	s.watcher = s.newWatcher()
+}
+
+func (s *watchTestSuite) TearDownTest() {
+	os.RemoveAll(s.dir)
+}
+
+func (s *watchTestSuite) newWatcher() *Watcher {
+	w, err := New(s.dir, s.ingest)
+	require.NoError(s.T(), err)
+	return w
+}
+
+// ingest records the files ingested, failing those named in s.fail.
+func (s *watchTestSuite) ingest(ctx context.Context, filename string) (parser.Stats, error) {
+	name := filepath.Base(filename)
+	s.ingested = append(s.ingested, name)
+	if s.fail[name] {
+		return parser.Stats{Rows: 1, Errors: 1}, errors.New("too many errors")
+	}
+	return parser.Stats{Rows: 1, Records: 1}, nil
+}
+
+func (s *watchTestSuite) write(name, content string) {
+	require.NoError(s.T(), ioutil.WriteFile(filepath.Join(s.dir, name), []byte(content), 0644))
+}
+
+func (s *watchTestSuite) poll(w *Watcher) {
+	require.NoError(s.T(), w.Poll(context.Background()))
+}
+
+func (s *watchTestSuite) requireFile(path ...string) {
+	_, err := os.Stat(filepath.Join(append([]string{s.dir}, path...)...))
+	require.NoError(s.T(), err)
+}
+
+func (s *watchTestSuite) manifest() []Entry {
+	entries, err := ReadManifest(filepath.Join(s.dir, ManifestFile))
+	require.NoError(s.T(), err)
+	return entries
+}
+
+func (s *watchTestSuite) Test_New_BadParameters_ReturnError() {
+	_, err := New("", s.ingest)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+
+	_, err = New(s.dir, nil)
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+
+	_, err = New(s.dir, s.ingest, WithInterval(0))
+	require.Equal(s.T(), ErrBadParameter, errors.Cause(err))
+}
+
+func (s *watchTestSuite) Test_Poll_NewFile_WaitsUntilUnchanged() {
+	s.write("monday.txt", "rice")
+	s.poll(s.watcher)
+	require.Empty(s.T(), s.ingested)
+
+	s.write("monday.txt", "rice and soda")
+	s.poll(s.watcher)
+	require.Empty(s.T(), s.ingested)
+
+	s.poll(s.watcher)
+	require.Equal(s.T(), []string{"monday.txt"}, s.ingested)
+	s.requireFile(ProcessedDir, "monday.txt")
+}
+
+func (s *watchTestSuite) Test_Poll_FailedIngest_MovesToFailed() {
+	s.fail["monday.txt"] = true
+	s.write("monday.txt", "rice")
+	s.poll(s.watcher)
+	s.poll(s.watcher)
+
+	s.requireFile(FailedDir, "monday.txt")
+
+	entries := s.manifest()
+	require.Len(s.T(), entries, 1)
+	require.Equal(s.T(), OutcomeFailed, entries[0].Outcome)
+	require.Equal(s.T(), "too many errors", entries[0].Error)
+	require.Equal(s.T(), 1, entries[0].Stats.Errors)
+	require.Equal(s.T(), filepath.Join(s.dir, FailedDir, "monday.txt"), entries[0].Path)
+}
+
+func (s *watchTestSuite) Test_Poll_ManifestRecordsEachFile() {
+	s.write("tuesday.txt", "soda")
+	s.write("monday.txt", "rice")
+	s.poll(s.watcher)
+	s.poll(s.watcher)
+
+	require.Equal(s.T(), []string{"monday.txt", "tuesday.txt"}, s.ingested)
+
+	entries := s.manifest()
+	require.Len(s.T(), entries, 2)
+	require.Equal(s.T(), "monday.txt", entries[0].File)
+	require.Equal(s.T(), OutcomeProcessed, entries[0].Outcome)
+	require.Equal(s.T(), int64(4), entries[0].Size)
+	require.Len(s.T(), entries[0].SHA256, 64)
+	require.False(s.T(), entries[0].Finished.Before(entries[0].Started))
+}
+
+func (s *watchTestSuite) Test_Poll_HiddenFile_IsIgnored() {
+	s.write(".monday.txt.part", "rice")
+	s.poll(s.watcher)
+	s.poll(s.watcher)
+
+	require.Empty(s.T(), s.ingested)
+	s.requireFile(".monday.txt.part")
+}
+
+func (s *watchTestSuite) Test_Poll_SameContent_IsNotIngestedAgain() {
+	s.write("monday.txt", "rice")
+	s.poll(s.watcher)
+	s.poll(s.watcher)
+
+	// A new watcher, as after a restart, knows the file from the manifest.
+	w := s.newWatcher()
+	s.write("monday.txt", "rice")
+	s.poll(w)
+	s.poll(w)
+
+	require.Equal(s.T(), []string{"monday.txt"}, s.ingested)
+	s.requireFile(ProcessedDir, "monday.txt.1")
+
+	entries := s.manifest()
+	require.Len(s.T(), entries, 2)
+	require.Equal(s.T(), OutcomeDuplicate, entries[1].Outcome)
+	require.Nil(s.T(), entries[1].Stats)
+}
+
+func (s *watchTestSuite) Test_Poll_SameContentAsFailed_IsIngestedAgain() {
+	s.fail["monday.txt"] = true
+	s.write("monday.txt", "rice")
+	s.poll(s.watcher)
+	s.poll(s.watcher)
+
+	s.write("monday.txt", "rice")
+	s.poll(s.watcher)
+	s.poll(s.watcher)
+
+	// A new watcher, as after a restart, retries the content too.
+	w := s.newWatcher()
+	s.fail["monday.txt"] = false
+	s.write("monday.txt", "rice")
+	s.poll(w)
+	s.poll(w)
+
+	require.Equal(s.T(), []string{"monday.txt", "monday.txt", "monday.txt"}, s.ingested)
+	s.requireFile(FailedDir, "monday.txt.1")
+	s.requireFile(ProcessedDir, "monday.txt")
+
+	entries := s.manifest()
+	require.Len(s.T(), entries, 3)
+	require.Equal(s.T(), OutcomeFailed, entries[0].Outcome)
+	require.Equal(s.T(), OutcomeFailed, entries[1].Outcome)
+	require.Equal(s.T(), OutcomeProcessed, entries[2].Outcome)
+}
+
+func (s *watchTestSuite) Test_Poll_FileNotMoved_IsNotRecorded() {
+	require.NoError(s.T(), os.Remove(filepath.Join(s.dir, ProcessedDir)))
+
+	s.write("monday.txt", "rice")
+	s.poll(s.watcher)
+	require.Error(s.T(), s.watcher.Poll(context.Background()))
+
+	s.requireFile("monday.txt")
+	require.Empty(s.T(), s.manifest())
+
+	// Once it can be moved, the file is taken again.
+	require.NoError(s.T(), os.Mkdir(filepath.Join(s.dir, ProcessedDir), 0755))
+	s.poll(s.watcher)
+	s.poll(s.watcher)
+
+	require.Equal(s.T(), []string{"monday.txt", "monday.txt"}, s.ingested)
+	s.requireFile(ProcessedDir, "monday.txt")
+	require.Len(s.T(), s.manifest(), 1)
+}
+
+func (s *watchTestSuite) Test_Poll_CancelledIngest_LeavesFile() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := New(s.dir, func(context.Context, string) (parser.Stats, error) {
+		cancel()
+		return parser.Stats{}, context.Canceled
+	})
+	require.NoError(s.T(), err)
+
+	s.write("monday.txt", "rice")
+	require.NoError(s.T(), w.Poll(ctx))
+	require.Error(s.T(), w.Poll(ctx))
+
+	s.requireFile("monday.txt")
+	require.Empty(s.T(), s.manifest())
+}